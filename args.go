@@ -17,12 +17,55 @@ type Args struct {
 	Completed []string
 	// Last argument in command line, the one being typed, if the last
 	// character in the command line is a space, this argument will be empty,
-	// otherwise this would be the last word.
+	// otherwise this would be the last word. If the last word has a "=", it
+	// is the text after the last "=", such as "b" in "a=b".
 	Last string
 	// LastCompleted is the last argument that was fully typed.
 	// If the last character in the command line is space, this would be the
-	// last word, otherwise, it would be the word before that.
+	// last word, otherwise, it would be the word before that. If the last
+	// word is of the form "-flag=value", it is "-flag". The text before the
+	// "=" of other words, such as "a" in "a=b", is not a completed argument.
 	LastCompleted string
+	// Dir is the working directory that relative paths, such as the files
+	// predicted by PredictFiles and PredictDirs, are relative to. If empty,
+	// paths are relative to the current working directory of the process.
+	// Predictors that read files should resolve relative paths against Dir,
+	// since the line may be completed for another process, such as by the
+	// completion daemon.
+	Dir string
+
+	// word is the last word in the command line, as it was typed. It differs
+	// from Last when the word is of the form "a=b", and Last is only "b".
+	word string
+	// logger is the logger of the completion.
+	logger Logger
+	// hints collects the hints of the predictors, it is nil when hints
+	// are not collected.
+	hints *[]string
+}
+
+// Hint adds a message that is shown to the user instead of, or along with,
+// the completion options, such as "<port 1-65535>". It is shown only by
+// shells that can display messages during completion.
+func (a Args) Hint(text string) {
+	if a.hints == nil || text == "" {
+		return
+	}
+	for _, h := range *a.hints {
+		if h == text {
+			return
+		}
+	}
+	*a.hints = append(*a.hints, text)
+}
+
+// Logger returns the logger of the completion, predictors should log with
+// it instead of with Log.
+func (a Args) Logger() Logger {
+	if a.logger == nil {
+		return defaultLogger
+	}
+	return a.logger
 }
 
 // Directory gives the directory of the current written
@@ -31,14 +74,14 @@ type Args struct {
 //
 // Deprecated.
 func (a Args) Directory() string {
-	if info, err := os.Stat(a.Last); err == nil && info.IsDir() {
-		return fixPathForm(a.Last, a.Last)
+	if info, err := os.Stat(resolve(a.Dir, a.Last)); err == nil && info.IsDir() {
+		return fixPathForm(a.Dir, a.Last, a.Last)
 	}
 	dir := filepath.Dir(a.Last)
-	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+	if info, err := os.Stat(resolve(a.Dir, dir)); err != nil || !info.IsDir() {
 		return "./"
 	}
-	return fixPathForm(a.Last, dir)
+	return fixPathForm(a.Dir, a.Last, dir)
 }
 
 func newArgs(line string) Args {
@@ -47,6 +90,10 @@ func newArgs(line string) Args {
 		completed []string
 	)
 	parts := splitFields(line)
+	var word string
+	if fields := strings.Fields(line); len(fields) > 0 && !unicode.IsSpace(rune(line[len(line)-1])) {
+		word = fields[len(fields)-1]
+	}
 	if len(parts) > 0 {
 		all = parts[1:]
 		completed = removeLast(parts[1:])
@@ -56,14 +103,16 @@ func newArgs(line string) Args {
 		Completed:     completed,
 		Last:          last(parts),
 		LastCompleted: last(completed),
+		word:          word,
 	}
 }
 
 // splitFields returns a list of fields from the given command line.
 // If the last character is space, it appends an empty field in the end
 // indicating that the field before it was completed.
-// If the last field is of the form "a=b", it splits it to two fields: "a", "b",
-// So it can be completed.
+// If the last field is of the form "-flag=value", it splits it to two fields:
+// "-flag", "value", so the value can be completed. The last field is only
+// the text after its last "=".
 func splitFields(line string) []string {
 	parts := strings.Fields(line)
 
@@ -81,8 +130,35 @@ func splitLastEqual(line []string) []string {
 	if len(line) == 0 {
 		return line
 	}
-	parts := strings.Split(line[len(line)-1], "=")
-	return append(line[:len(line)-1], parts...)
+	last := line[len(line)-1]
+	i := strings.LastIndex(last, "=")
+	if i < 0 {
+		return line
+	}
+	line = line[:len(line)-1]
+	// A flag of the form "-flag=value" is completed as if the flag was a
+	// separate field.
+	if strings.HasPrefix(last, "-") {
+		line = append(line, last[:strings.Index(last, "=")])
+	}
+	// Shells complete the text after the last "=", such as "b" in "a=b".
+	return append(line, last[i+1:])
+}
+
+// value returns the typed value of the last word, without the flag of the
+// form "-flag=value". Unlike Last, it includes the text before any "=" in
+// the value, such as "a=b" in "-flag=a=b".
+func (a Args) value() string {
+	word := a.word
+	if word == "" {
+		// Args that were not parsed from a line, such as Args that are built
+		// by hand, have only the exported fields.
+		word = a.Last
+	}
+	if flag := a.LastCompleted; strings.HasPrefix(flag, "-") && strings.HasPrefix(word, flag+"=") {
+		return word[len(flag)+1:]
+	}
+	return word
 }
 
 // from returns a copy of Args of all arguments after the i'th argument.
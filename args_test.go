@@ -46,6 +46,36 @@ func TestArgs(t *testing.T) {
 			last:          "",
 			lastCompleted: "",
 		},
+		{
+			line:          "a -flag=b",
+			completed:     "-flag",
+			last:          "b",
+			lastCompleted: "-flag",
+		},
+		{
+			line:          "a -flag key=b",
+			completed:     "-flag",
+			last:          "b",
+			lastCompleted: "-flag",
+		},
+		{
+			line:          "a x key=b",
+			completed:     "x",
+			last:          "b",
+			lastCompleted: "x",
+		},
+		{
+			line:          "a key=",
+			completed:     "",
+			last:          "",
+			lastCompleted: "",
+		},
+		{
+			line:          "a -flag=key=b",
+			completed:     "-flag",
+			last:          "b",
+			lastCompleted: "-flag",
+		},
 	}
 
 	for _, tt := range tests {
@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/posener/complete/cmd/install"
@@ -19,7 +20,16 @@ type CLI struct {
 
 	install   bool
 	uninstall bool
+	status    bool
 	yes       bool
+	dryRun    bool
+
+	shells        string
+	target        string
+	completionDir bool
+	system        bool
+	root          string
+	lookup        bool
 }
 
 const (
@@ -37,13 +47,35 @@ func (f *CLI) Run() bool {
 		os.Exit(1)
 	}
 
+	var opts []install.Option
+	if f.dryRun {
+		opts = append(opts, install.DryRun(os.Stdout))
+	}
+	if shells := f.shellNames(); len(shells) > 0 {
+		opts = append(opts, install.Shells(shells...))
+		if f.target != "" {
+			opts = append(opts, install.Target(shells[0], f.target))
+		}
+	}
+	if f.completionDir {
+		opts = append(opts, install.CompletionDir())
+	}
+	if f.system {
+		opts = append(opts, install.System(f.root))
+	}
+	if f.lookup {
+		opts = append(opts, install.Lookup())
+	}
+
 	switch {
+	case f.status:
+		err = f.printStatus(opts)
 	case f.install:
 		f.prompt()
-		err = install.Install(f.Name)
+		err = install.Install(f.Name, opts...)
 	case f.uninstall:
 		f.prompt()
-		err = install.Uninstall(f.Name)
+		err = install.Uninstall(f.Name, opts...)
 	default:
 		// non of the action flags matched,
 		// returning false should make the real program execute
@@ -54,13 +86,41 @@ func (f *CLI) Run() bool {
 		fmt.Printf("%s failed! %s\n", f.action(), err)
 		os.Exit(3)
 	}
-	fmt.Println("Done!")
+	if !f.dryRun && !f.status {
+		fmt.Println("Done!")
+	}
+	if f.install && f.completionDir && !f.dryRun {
+		f.printZshFpath(opts)
+	}
 	return true
 }
 
+// printZshFpath prints the line that adds the directory of the installed zsh
+// completion file to the zsh fpath. zsh does not export the fpath, so unless
+// FPATH is exported, the directory may not be in it.
+func (f *CLI) printZshFpath(opts []install.Option) {
+	if os.Getenv("FPATH") != "" {
+		return
+	}
+	statuses, err := install.Status(f.Name, opts...)
+	if err != nil {
+		return
+	}
+	for _, s := range statuses {
+		if s.Shell == "zsh" && s.Installed {
+			fmt.Printf("Add the completion directory to the zsh fpath in ~/.zshrc, before compinit:\n\tfpath+=(%s)\n",
+				filepath.Dir(s.File))
+		}
+	}
+}
+
 // prompt use for approval
 // exit if approval was not given
 func (f *CLI) prompt() {
+	if f.dryRun {
+		// Nothing is changed, no need for approval.
+		return
+	}
 	defer fmt.Println(f.action() + "ing...")
 	if f.yes {
 		return
@@ -105,6 +165,40 @@ func (f *CLI) AddFlags(flags *flag.FlagSet) {
 	if flags.Lookup("y") == nil {
 		flags.BoolVar(&f.yes, "y", false, "Don't prompt user for typing 'yes' when installing completion")
 	}
+	if flags.Lookup("completion-dry-run") == nil {
+		flags.BoolVar(&f.dryRun, "completion-dry-run", false,
+			"Print the changes that installing or uninstalling completion would make, without making them")
+	}
+	if flags.Lookup("completion-status") == nil {
+		flags.BoolVar(&f.status, "completion-status", false,
+			fmt.Sprintf("Print the status of the completion for %s command in every shell", f.Name))
+	}
+	if flags.Lookup("completion-lookup") == nil {
+		flags.BoolVar(&f.lookup, "completion-lookup", false,
+			fmt.Sprintf("Install completion that looks up %s in $PATH instead of running this binary by its path", f.Name))
+	}
+	if flags.Lookup("completion-shell") == nil {
+		flags.StringVar(&f.shells, "completion-shell", "",
+			"Comma separated shells to install or uninstall completion for, instead of all the detected shells")
+	}
+	if flags.Lookup("completion-target") == nil {
+		flags.StringVar(&f.target, "completion-target", "",
+			"File, or directory, to install or uninstall completion in, for the single shell given in -completion-shell")
+	}
+	if flags.Lookup("completion-dir") == nil {
+		flags.BoolVar(&f.completionDir, "completion-dir", false,
+			"Install or uninstall bash and zsh completion in a completion directory instead of in the rc file. "+
+				"Unless FPATH is exported, zsh completion is installed in $XDG_DATA_HOME/zsh/site-functions, "+
+				"which should be added to the fpath in ~/.zshrc, or give the directory with -completion-target")
+	}
+	if flags.Lookup("completion-system") == nil {
+		flags.BoolVar(&f.system, "completion-system", false,
+			"Install or uninstall completion in the system wide completion directories")
+	}
+	if flags.Lookup("completion-root") == nil {
+		flags.StringVar(&f.root, "completion-root", os.Getenv("DESTDIR"),
+			"Root directory prefix of the system wide completion directories, used to stage package builds")
+	}
 }
 
 // validate the CLI
@@ -112,6 +206,50 @@ func (f *CLI) validate() error {
 	if f.install && f.uninstall {
 		return errors.New("Install and uninstall are mutually exclusive")
 	}
+	if f.status && (f.install || f.uninstall) {
+		return errors.New("Completion status is mutually exclusive with install and uninstall")
+	}
+	if f.target != "" && len(f.shellNames()) != 1 {
+		return errors.New("Completion target requires a single completion shell")
+	}
+	return nil
+}
+
+// shellNames returns the shells given in the completion shell flag.
+func (f *CLI) shellNames() []string {
+	var names []string
+	for _, name := range strings.Split(f.shells, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// printStatus prints the status of the completion in every shell.
+func (f *CLI) printStatus(opts []install.Option) error {
+	statuses, err := install.Status(f.Name, opts...)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if !s.Installed {
+			fmt.Printf("%s: not installed (%s)\n", s.Shell, s.File)
+			continue
+		}
+		var binary string
+		switch {
+		case s.Binary == "":
+			binary = "unknown binary"
+		case !s.BinaryExists:
+			binary = s.Binary + " (missing)"
+		case !s.Current:
+			binary = s.Binary + " (not the running binary)"
+		default:
+			binary = s.Binary
+		}
+		fmt.Printf("%s: installed in %s, runs %s\n", s.Shell, s.File, binary)
+	}
 	return nil
 }
 
@@ -122,6 +260,8 @@ func (f *CLI) action() string {
 		return "Install"
 	case f.uninstall:
 		return "Uninstall"
+	case f.status:
+		return "Status"
 	default:
 		return "unknown"
 	}
@@ -1,37 +1,91 @@
 package install
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+)
 
 // (un)install in bash
-// basically adds/remove from .bashrc:
+// basically adds/remove from .bashrc a block with:
 //
 // complete -C </path/to/completion/command> <command>
+//
+// Or, when the completion command is looked up in $PATH:
+//
+// if command -v <command> >/dev/null 2>&1; then complete -C "$(command -v <command>)" <command>; fi
 type bash struct {
+	ed *editor
 	rc string
 }
 
+func (bash) Name() string {
+	return "bash"
+}
+
 func (b bash) IsInstalled(cmd, bin string) bool {
-	completeCmd := b.cmd(cmd, bin)
-	return lineInFile(b.rc, completeCmd)
+	return b.block(cmd).isInstalled()
 }
 
 func (b bash) Install(cmd, bin string) error {
-	if b.IsInstalled(cmd, bin) {
+	block, completeCmd := b.block(cmd), b.cmd(cmd, bin)
+	if block.has(completeCmd) {
 		return fmt.Errorf("already installed in %s", b.rc)
 	}
-	completeCmd := b.cmd(cmd, bin)
-	return appendToFile(b.rc, completeCmd)
+	return block.install(completeCmd)
 }
 
 func (b bash) Uninstall(cmd, bin string) error {
-	if !b.IsInstalled(cmd, bin) {
-		return fmt.Errorf("does not installed in %s", b.rc)
-	}
+	return b.block(cmd).uninstall()
+}
 
-	completeCmd := b.cmd(cmd, bin)
-	return removeFromFile(b.rc, completeCmd)
+func (b bash) block(cmd string) rcBlock {
+	// Older versions wrote the complete command without a block.
+	return rcBlock{ed: b.ed, rc: b.rc, cmd: cmd, legacy: legacyComplete(cmd)}
 }
 
+// cmd returns the complete command. If bin is not a path, it is looked up
+// in $PATH, and the completion is registered only if it is found.
 func (bash) cmd(cmd, bin string) string {
+	if !filepath.IsAbs(bin) {
+		return fmt.Sprintf(`if command -v %s >/dev/null 2>&1; then complete -C "$(command -v %s)" %s; fi`, bin, bin, cmd)
+	}
 	return fmt.Sprintf("complete -C %s %s", bin, cmd)
 }
+
+// (un)install in the bash-completion completions directory
+// basically creates a completion file, that bash-completion loads on the
+// first completion of the command:
+//
+// <completions dir>/<command>
+type bashCompletion struct {
+	ed  *editor
+	dir string
+}
+
+func (bashCompletion) Name() string {
+	return "bash"
+}
+
+func (b bashCompletion) IsInstalled(cmd, bin string) bool {
+	return b.ed.exists(b.file(cmd))
+}
+
+func (b bashCompletion) Install(cmd, bin string) error {
+	return b.ed.installFile(b.file(cmd), bash{}.cmd(cmd, bin))
+}
+
+func (b bashCompletion) Uninstall(cmd, bin string) error {
+	return b.ed.uninstallFile(b.file(cmd))
+}
+
+func (b bashCompletion) file(cmd string) string {
+	return filepath.Join(b.dir, cmd)
+}
+
+func (b bash) completion(cmd, bin string) (string, string, error) {
+	return b.rc, b.cmd(cmd, bin), nil
+}
+
+func (b bashCompletion) completion(cmd, bin string) (string, string, error) {
+	return b.file(cmd), bash{}.cmd(cmd, bin), nil
+}
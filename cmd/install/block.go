@@ -0,0 +1,172 @@
+package install
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// rcBlock is the completion of a command in an rc file of a shell.
+// It is written between begin and end marker lines that name the command,
+// so it can be found, upgraded and removed as a unit:
+//
+// # BEGIN complete: <command>
+// <content>
+// # END complete: <command>
+type rcBlock struct {
+	ed  *editor
+	rc  string
+	cmd string
+	// legacy matches the lines that older versions wrote to the rc file
+	// without the markers. They are removed when the block is installed or
+	// uninstalled.
+	legacy *regexp.Regexp
+}
+
+// legacyComplete matches the complete commands that older versions wrote
+// for cmd, with any completion binary path, as the binary may have been
+// moved or reinstalled since:
+//
+// complete [-o nospace] -C <path> <command>
+func legacyComplete(cmd string) *regexp.Regexp {
+	return regexp.MustCompile(`^complete (-o nospace )?-C .+ ` + regexp.QuoteMeta(cmd) + `$`)
+}
+
+func (b rcBlock) begin() string {
+	return "# BEGIN complete: " + b.cmd
+}
+
+func (b rcBlock) end() string {
+	return "# END complete: " + b.cmd
+}
+
+// isInstalled returns true if the block, or any of the legacy lines, are in
+// the rc file.
+func (b rcBlock) isInstalled() bool {
+	lines, err := b.ed.readLines(b.rc)
+	if err != nil {
+		return false
+	}
+	if _, _, ok, _ := b.find(lines); ok {
+		return true
+	}
+	for _, line := range lines {
+		if b.isLegacy(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// has returns true if the block is in the rc file with the given content.
+func (b rcBlock) has(content string) bool {
+	lines, err := b.ed.readLines(b.rc)
+	if err != nil {
+		return false
+	}
+	begin, end, ok, _ := b.find(lines)
+	return ok && equalLines(lines[begin:end+1], b.lines(content))
+}
+
+// install writes the block with the given content to the rc file.
+// If the block already exists, it is replaced in place.
+func (b rcBlock) install(content string) error {
+	return b.ed.rewriteFile(b.rc, func(lines []string) ([]string, error) {
+		begin, end, ok, err := b.find(lines)
+		if err != nil {
+			return nil, err
+		}
+		block := b.lines(content)
+		if ok {
+			block = append(block, lines[end+1:]...)
+			lines = append(lines[:begin], block...)
+		} else {
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+			lines = append(lines, block...)
+		}
+		return b.removeLegacy(lines), nil
+	})
+}
+
+// uninstall removes the block from the rc file.
+func (b rcBlock) uninstall() error {
+	if !b.isInstalled() {
+		return fmt.Errorf("does not installed in %s", b.rc)
+	}
+	return b.ed.rewriteFile(b.rc, func(lines []string) ([]string, error) {
+		begin, end, ok, err := b.find(lines)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			// Remove the empty line that was added before the block.
+			if begin > 0 && lines[begin-1] == "" {
+				begin--
+			}
+			lines = append(lines[:begin], lines[end+1:]...)
+		}
+		return b.removeLegacy(lines), nil
+	})
+}
+
+// lines returns the lines of the block with the given content.
+func (b rcBlock) lines(content string) []string {
+	lines := []string{b.begin()}
+	lines = append(lines, strings.Split(content, "\n")...)
+	return append(lines, b.end())
+}
+
+// find returns the indices of the begin and end marker lines of the block.
+func (b rcBlock) find(lines []string) (begin, end int, ok bool, err error) {
+	begin, end = -1, -1
+	for i, line := range lines {
+		switch {
+		case line == b.begin() && begin == -1:
+			begin = i
+		case line == b.end() && begin != -1:
+			return begin, i, true, nil
+		}
+	}
+	if begin != -1 {
+		return 0, 0, false, fmt.Errorf("completion block of %s in %s has no end marker", b.cmd, b.rc)
+	}
+	return 0, 0, false, nil
+}
+
+func (b rcBlock) isLegacy(line string) bool {
+	return b.legacy != nil && b.legacy.MatchString(line)
+}
+
+// removeLegacy removes the legacy lines that are outside of the block.
+func (b rcBlock) removeLegacy(lines []string) []string {
+	var (
+		kept    = lines[:0]
+		inBlock = false
+	)
+	for _, line := range lines {
+		switch line {
+		case b.begin():
+			inBlock = true
+		case b.end():
+			inBlock = false
+		}
+		if inBlock || !b.isLegacy(line) {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRCBlock(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rc := filepath.Join(dir, ".bashrc")
+	const original = "export A=1\ncomplete -C /old/bin cmd\n"
+	require.NoError(t, ioutil.WriteFile(rc, []byte(original), 0644))
+
+	b := rcBlock{ed: &editor{}, rc: rc, cmd: "cmd", legacy: legacyComplete("cmd")}
+	assert.True(t, b.isInstalled(), "legacy line should be detected")
+
+	// Install replaces the legacy line with a block.
+	require.NoError(t, b.install("complete -C /bin/cmd cmd"))
+	assertFile(t, rc, "export A=1\n\n# BEGIN complete: cmd\ncomplete -C /bin/cmd cmd\n# END complete: cmd\n")
+	assert.True(t, b.has("complete -C /bin/cmd cmd"))
+	assert.False(t, b.has("complete -C /new/bin/cmd cmd"))
+
+	// Other lines that are added after the block are kept on upgrade.
+	appendFile(t, rc, "export B=2\n")
+	require.NoError(t, b.install("complete -C /new/bin/cmd cmd"))
+	assertFile(t, rc, "export A=1\n\n# BEGIN complete: cmd\ncomplete -C /new/bin/cmd cmd\n# END complete: cmd\nexport B=2\n")
+
+	// Blocks of other commands are not affected.
+	other := rcBlock{ed: &editor{}, rc: rc, cmd: "other"}
+	assert.False(t, other.isInstalled())
+	assert.Error(t, other.uninstall())
+
+	require.NoError(t, b.uninstall())
+	assertFile(t, rc, "export A=1\nexport B=2\n")
+	assert.False(t, b.isInstalled())
+	assert.Error(t, b.uninstall())
+}
+
+func TestRCBlock_NoEndMarker(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rc := filepath.Join(dir, ".bashrc")
+	const content = "# BEGIN complete: cmd\ncomplete -C /bin/cmd cmd\n"
+	require.NoError(t, ioutil.WriteFile(rc, []byte(content), 0644))
+
+	b := rcBlock{ed: &editor{}, rc: rc, cmd: "cmd"}
+	assert.Error(t, b.install("complete -C /bin/cmd cmd"))
+	assert.Error(t, b.uninstall())
+	assertFile(t, rc, content)
+}
+
+func assertFile(t *testing.T, name, want string) {
+	t.Helper()
+	got, err := ioutil.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+}
+
+func appendFile(t *testing.T, name, content string) {
+	t.Helper()
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+}
@@ -0,0 +1,232 @@
+package install
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/posener/complete/internal/diff"
+)
+
+// editor reads and modifies the files that the installers change.
+// In dry-run mode, it records the changes instead of applying them, and
+// later reads of the changed files return their recorded content.
+type editor struct {
+	dryRun bool
+	// shell is the name of the shell whose installer is running, it is
+	// attached to the recorded changes.
+	shell   string
+	changes []*change
+}
+
+// change is a recorded change of a file.
+type change struct {
+	shell string
+	name  string
+	// old and new are the content of the file before and after the change.
+	// exists and removed tell if the file existed before the change, or was
+	// removed by it.
+	old, new        string
+	exists, removed bool
+}
+
+// read returns the content of a file, including the recorded changes.
+func (e *editor) read(name string) ([]byte, error) {
+	if c := e.change(name); c != nil {
+		if c.removed {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return []byte(c.new), nil
+	}
+	return ioutil.ReadFile(name)
+}
+
+// exists returns true if the file exists.
+func (e *editor) exists(name string) bool {
+	if c := e.change(name); c != nil {
+		return !c.removed
+	}
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// readLines returns the lines of a file, a file that does not exist has no
+// lines.
+func (e *editor) readLines(name string) ([]string, error) {
+	data, err := e.read(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil
+}
+
+// fileContent returns the content of a file, as it would be written by
+// createFile.
+func (e *editor) fileContent(name string) (string, bool) {
+	data, err := e.read(name)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(string(data), "\n"), true
+}
+
+func (e *editor) createFile(name string, content string) error {
+	if e.dryRun {
+		e.record(name, content+"\n", false)
+		return nil
+	}
+
+	// make sure file directory exists
+	if err := os.MkdirAll(filepath.Dir(name), 0775); err != nil {
+		return err
+	}
+
+	return writeFile(name, content+"\n")
+}
+
+// rewriteFile replaces the lines of a file with the lines returned by edit.
+// If the file does not exist, it is created. If it exists, a backup of its
+// previous content is kept next to it. A file whose content does not change
+// is not written.
+func (e *editor) rewriteFile(name string, edit func(lines []string) ([]string, error)) error {
+	lines, err := e.readLines(name)
+	if err != nil {
+		return err
+	}
+	lines, err = edit(lines)
+	if err != nil {
+		return err
+	}
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if e.dryRun {
+		e.record(name, content, false)
+		return nil
+	}
+	if old, err := ioutil.ReadFile(name); err == nil && string(old) == content {
+		return nil
+	}
+	if _, err := backupFile(name); err != nil {
+		return fmt.Errorf("failed backing up %s: %v", name, err)
+	}
+	return writeFile(name, content)
+}
+
+// remove removes a file, it is not an error if the file does not exist.
+func (e *editor) remove(name string) error {
+	if e.dryRun {
+		if e.exists(name) {
+			e.record(name, "", true)
+		}
+		return nil
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (e *editor) chmod(name string, mode os.FileMode) error {
+	if e.dryRun {
+		return nil
+	}
+	return os.Chmod(name, mode)
+}
+
+// installFile creates a completion file. If the file exists with other
+// content, it is upgraded in place.
+func (e *editor) installFile(name, content string) error {
+	if old, ok := e.fileContent(name); ok && old == content {
+		return fmt.Errorf("already installed at %s", name)
+	}
+	return e.createFile(name, content)
+}
+
+// uninstallFile removes a completion file.
+func (e *editor) uninstallFile(name string) error {
+	if !e.exists(name) {
+		return fmt.Errorf("does not installed at %s", name)
+	}
+	return e.remove(name)
+}
+
+// installScript creates a completion script file, and adds a hook that
+// loads it to the rc file of the shell. If the script or the hook changed,
+// they are upgraded in place.
+func (e *editor) installScript(rc rcBlock, hook, script, content string) error {
+	if old, ok := e.fileContent(script); ok && old == content && rc.has(hook) {
+		return fmt.Errorf("already installed in %s", rc.rc)
+	}
+	if err := e.createFile(script, content); err != nil {
+		return err
+	}
+	return rc.install(hook)
+}
+
+// uninstallScript removes the hook from the rc file of the shell, and
+// removes the completion script file.
+func (e *editor) uninstallScript(rc rcBlock, script string) error {
+	if err := rc.uninstall(); err != nil {
+		return err
+	}
+	return e.remove(script)
+}
+
+// change returns the recorded change of a file, or nil if it was not
+// changed.
+func (e *editor) change(name string) *change {
+	for _, c := range e.changes {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// record records a change of a file. Consecutive changes of the same file
+// are merged.
+func (e *editor) record(name, content string, removed bool) {
+	c := e.change(name)
+	if c == nil {
+		c = &change{shell: e.shell, name: name}
+		if data, err := ioutil.ReadFile(name); err == nil {
+			c.old, c.exists = string(data), true
+		}
+		e.changes = append(e.changes, c)
+	}
+	c.new, c.removed = content, removed
+}
+
+// print writes the recorded changes, per shell, as unified diffs.
+func (e *editor) print(w io.Writer) {
+	for _, c := range e.changes {
+		oldName, newName := c.name, c.name
+		if !c.exists {
+			oldName = "/dev/null"
+		}
+		if c.removed {
+			newName = "/dev/null"
+		}
+		d := diff.Unified(oldName, newName, c.old, c.new)
+		if d == "" {
+			continue
+		}
+		if c.shell != "" {
+			fmt.Fprintf(w, "%s: %s\n", c.shell, c.name)
+		} else {
+			fmt.Fprintf(w, "%s\n", c.name)
+		}
+		fmt.Fprint(w, d)
+	}
+}
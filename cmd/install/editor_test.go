@@ -0,0 +1,101 @@
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor_DryRun(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rc := filepath.Join(dir, ".zshrc")
+	require.NoError(t, ioutil.WriteFile(rc, []byte("export A=1\n"), 0644))
+	fishDir := filepath.Join(dir, "fish")
+
+	ed := &editor{dryRun: true}
+	ed.shell = "zsh"
+	z := zsh{ed: ed, rc: rc}
+	require.NoError(t, z.Install("cmd", "/bin/cmd"))
+	assert.True(t, z.IsInstalled("cmd", "/bin/cmd"), "dry-run should read its own changes")
+	ed.shell = "fish"
+	require.NoError(t, fish{ed: ed, dir: filepath.Join(fishDir, "completions")}.Install("cmd", "/bin/cmd"))
+
+	// Nothing was changed.
+	assertFile(t, rc, "export A=1\n")
+	_, err = os.Stat(fishDir)
+	assert.True(t, os.IsNotExist(err))
+
+	var out bytes.Buffer
+	ed.print(&out)
+	fishFile := filepath.Join(fishDir, "completions", "cmd.fish")
+	zshLines := strings.Split(z.cmd("cmd", "/bin/cmd"), "\n")
+	fishContent, err := fish{}.cmd("cmd", "/bin/cmd")
+	require.NoError(t, err)
+	fishLines := strings.Split(fishContent, "\n")
+	assert.Equal(t, "zsh: "+rc+"\n"+
+		"--- "+rc+"\n"+
+		"+++ "+rc+"\n"+
+		fmt.Sprintf("@@ -1 +1,%d @@\n", len(zshLines)+4)+
+		" export A=1\n"+
+		"+\n"+
+		"+# BEGIN complete: cmd\n"+
+		added(zshLines)+
+		"+# END complete: cmd\n"+
+		"fish: "+fishFile+"\n"+
+		"--- /dev/null\n"+
+		"+++ "+fishFile+"\n"+
+		fmt.Sprintf("@@ -0,0 +1,%d @@\n", len(fishLines))+
+		added(fishLines),
+		out.String())
+	assert.Contains(t, zshLines, "compdef _complete_cmd cmd")
+}
+
+// added returns the lines as added lines of a unified diff.
+func added(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString("+" + line + "\n")
+	}
+	return b.String()
+}
+
+func TestEditor_DryRunUninstall(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rc := filepath.Join(dir, ".bashrc")
+	const content = "export A=1\n\n# BEGIN complete: cmd\ncomplete -C /bin/cmd cmd\n# END complete: cmd\n"
+	require.NoError(t, ioutil.WriteFile(rc, []byte(content), 0644))
+
+	ed := &editor{dryRun: true, shell: "bash"}
+	require.NoError(t, bash{ed: ed, rc: rc}.Uninstall("cmd", "/bin/cmd"))
+	assertFile(t, rc, content)
+
+	var out bytes.Buffer
+	ed.print(&out)
+	assert.Equal(t, "bash: "+rc+"\n"+
+		"--- "+rc+"\n"+
+		"+++ "+rc+"\n"+
+		"@@ -1,5 +1 @@\n"+
+		" export A=1\n"+
+		"-\n"+
+		"-# BEGIN complete: cmd\n"+
+		"-complete -C /bin/cmd cmd\n"+
+		"-# END complete: cmd\n",
+		out.String())
+}
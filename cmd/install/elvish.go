@@ -0,0 +1,86 @@
+package install
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// (un)install in elvish
+// basically creates a completion module that sets an argument completer that
+// runs the completion command, and adds/remove from rc.elv a block with
+// a line that loads it:
+//
+// use complete-<command>
+type elvish struct {
+	ed        *editor
+	configDir string
+	rc        string
+}
+
+func (elvish) Name() string {
+	return "elvish"
+}
+
+func (e elvish) IsInstalled(cmd, bin string) bool {
+	return e.block(cmd).isInstalled()
+}
+
+func (e elvish) Install(cmd, bin string) error {
+	content, err := e.script(cmd, bin)
+	if err != nil {
+		return err
+	}
+	return e.ed.installScript(e.block(cmd), e.hook(cmd), e.scriptPath(cmd), content)
+}
+
+func (e elvish) Uninstall(cmd, bin string) error {
+	return e.ed.uninstallScript(e.block(cmd), e.scriptPath(cmd))
+}
+
+func (e elvish) block(cmd string) rcBlock {
+	return rcBlock{ed: e.ed, rc: e.rc, cmd: cmd}
+}
+
+func (elvish) module(cmd string) string {
+	return "complete-" + cmd
+}
+
+func (e elvish) scriptPath(cmd string) string {
+	return filepath.Join(e.configDir, "lib", e.module(cmd)+".elv")
+}
+
+func (e elvish) hook(cmd string) string {
+	return "use " + e.module(cmd)
+}
+
+func (elvish) script(cmd, bin string) (string, error) {
+	var buf bytes.Buffer
+	params := struct{ Cmd, Bin string }{elvishQuote(cmd), elvishQuote(bin)}
+	tmpl := template.Must(template.New("elvish").Parse(`
+use str
+set edit:completion:arg-completer[{{.Cmd}}] = {|@words|
+    var line = (str:join ' ' $words)
+    tmp E:COMP_LINE = $line
+    tmp E:COMP_POINT = (to-string (count $line))
+    tmp E:COMP_SHELL = elvish
+    (external {{.Bin}}) | from-lines
+}
+`))
+	err := tmpl.Execute(&buf, params)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// elvishQuote quotes s as an elvish single quoted string.
+func elvishQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func (e elvish) completion(cmd, bin string) (string, string, error) {
+	content, err := e.script(cmd, bin)
+	return e.scriptPath(cmd), content, err
+}
@@ -3,50 +3,44 @@ package install
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"path/filepath"
 	"text/template"
 )
 
 // (un)install in fish
-
+// basically creates a completion file in the fish completions directory:
+//
+// <completions dir>/<command>.fish
+//
+// The completion function prints the hints of the completion command above
+// the prompt, and not as options, so they can not be selected.
 type fish struct {
-	configDir string
+	ed  *editor
+	dir string
+}
+
+func (fish) Name() string {
+	return "fish"
 }
 
 func (f fish) IsInstalled(cmd, bin string) bool {
-	completionFile := f.getCompletionFilePath(cmd)
-	if _, err := os.Stat(completionFile); err == nil {
-		return true
-	}
-	return false
+	return f.ed.exists(f.getCompletionFilePath(cmd))
 }
 
 func (f fish) Install(cmd, bin string) error {
-	if f.IsInstalled(cmd, bin) {
-		return fmt.Errorf("already installed at %s", f.getCompletionFilePath(cmd))
-	}
-
-	completionFile := f.getCompletionFilePath(cmd)
 	completeCmd, err := f.cmd(cmd, bin)
 	if err != nil {
 		return err
 	}
-
-	return createFile(completionFile, completeCmd)
+	return f.ed.installFile(f.getCompletionFilePath(cmd), completeCmd)
 }
 
 func (f fish) Uninstall(cmd, bin string) error {
-	if !f.IsInstalled(cmd, bin) {
-		return fmt.Errorf("does not installed in %s", f.configDir)
-	}
-
-	completionFile := f.getCompletionFilePath(cmd)
-	return os.Remove(completionFile)
+	return f.ed.uninstallFile(f.getCompletionFilePath(cmd))
 }
 
 func (f fish) getCompletionFilePath(cmd string) string {
-	return filepath.Join(f.configDir, "completions", fmt.Sprintf("%s.fish", cmd))
+	return filepath.Join(f.dir, fmt.Sprintf("%s.fish", cmd))
 }
 
 func (f fish) cmd(cmd, bin string) (string, error) {
@@ -55,9 +49,20 @@ func (f fish) cmd(cmd, bin string) (string, error) {
 	tmpl := template.Must(template.New("cmd").Parse(`
 function __complete_{{.Cmd}}
     set -lx COMP_LINE (commandline -cp)
+    set -lx COMP_SHELL fish
     test -z (commandline -ct)
     and set COMP_LINE "$COMP_LINE "
-    {{.Bin}}
+    set -l hints
+    for m in ({{.Bin}})
+        if string match -q -- \t'*' "$m"
+            set hints $hints (string sub -s 2 -- "$m")
+        else if test -n "$m"
+            printf '%s\n' $m
+        end
+    end
+    if set -q hints[1]; and functions -q __fish_echo
+        __fish_echo string join ', ' -- $hints
+    end
 end
 complete -f -c {{.Cmd}} -a "(__complete_{{.Cmd}})"
 `))
@@ -67,3 +72,8 @@ complete -f -c {{.Cmd}} -a "(__complete_{{.Cmd}})"
 	}
 	return buf.String(), nil
 }
+
+func (f fish) completion(cmd, bin string) (string, string, error) {
+	content, err := f.cmd(cmd, bin)
+	return f.getCompletionFilePath(cmd), content, err
+}
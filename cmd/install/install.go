@@ -2,33 +2,178 @@ package install
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 )
 
 type installer interface {
+	Name() string
 	IsInstalled(cmd, bin string) bool
 	Install(cmd, bin string) error
 	Uninstall(cmd, bin string) error
+	// completion returns the file that the completion command is written
+	// in, and the content of the completion command.
+	completion(cmd, bin string) (file, content string, err error)
+}
+
+// Option configures Install and Uninstall.
+type Option func(*options)
+
+type options struct {
+	dryRun io.Writer
+	// shells are the names of the shells to (un)install in, all the detected
+	// shells if empty.
+	shells []string
+	// targets are the files, or directories in completion directory mode,
+	// to (un)install in, by shell name.
+	targets map[string]string
+	// completionDir makes bash and zsh (un)install a completion file in a
+	// completion directory instead of in their rc file.
+	completionDir bool
+	// system makes the installers (un)install in the system completion
+	// directories, under the root directory.
+	system bool
+	root   string
+	// lookup makes the completion look up the command in $PATH when it
+	// runs, instead of running the binary by its path.
+	lookup bool
+}
+
+// shellNames are the names of the shells that completion can be installed
+// in, in the order they are installed.
+var shellNames = []string{"bash", "zsh", "fish", "tcsh", "pwsh", "nushell", "elvish", "xonsh"}
+
+// DryRun makes Install and Uninstall write the changes that they would make,
+// per detected shell, as unified diffs to w, without changing any file.
+func DryRun(w io.Writer) Option {
+	return func(o *options) {
+		o.dryRun = w
+	}
+}
+
+// Shells restricts Install, Uninstall and IsInstalled to the given shells.
+// A selected shell that its configuration was not found is an error, unless
+// a target was given for it.
+func Shells(names ...string) Option {
+	return func(o *options) {
+		o.shells = append(o.shells, names...)
+	}
+}
+
+// Target sets the file that the completion of the given shell is
+// (un)installed in, instead of the detected rc file. For fish, and in
+// completion directory mode, it is the directory of the completion file.
+func Target(shell, path string) Option {
+	return func(o *options) {
+		if o.targets == nil {
+			o.targets = make(map[string]string)
+		}
+		o.targets[shell] = path
+	}
+}
+
+// CompletionDir makes the completion of bash and zsh be (un)installed as a
+// file in a completion directory that the shell loads completions from,
+// instead of in their rc file: the bash-completion user directory for bash,
+// and a directory in the zsh fpath for zsh. zsh does not export its fpath, so
+// unless FPATH is exported, zsh completion of users with a ~/.zshrc is
+// installed in $XDG_DATA_HOME/zsh/site-functions, which should be added to
+// the fpath in ~/.zshrc, before compinit runs:
+//
+//	fpath+=(${XDG_DATA_HOME:-$HOME/.local/share}/zsh/site-functions)
+//
+// Shells that have no completion directory are skipped, or are an error if
+// they were selected with Shells.
+func CompletionDir() Option {
+	return func(o *options) {
+		o.completionDir = true
+	}
+}
+
+// System makes Install, Uninstall and IsInstalled use the system wide
+// completion directories instead of the configuration of the current user:
+//
+//	bash: /usr/share/bash-completion/completions
+//	zsh:  /usr/share/zsh/site-functions
+//	fish: /usr/share/fish/vendor_completions.d
+//
+// The directories are prefixed with root, which can be used, like DESTDIR,
+// to stage the files of a package build. If the running binary is under
+// root, the completion runs it from its path without the root prefix.
+// Shells that have no system completion directory are skipped, or are an
+// error if they were selected with Shells.
+func System(root string) Option {
+	return func(o *options) {
+		o.system = true
+		o.root = root
+	}
+}
+
+// Lookup makes the installed completion find the binary by looking up the
+// command name in $PATH when it runs, instead of running the binary by its
+// path, so upgrading or moving the binary does not break the completion.
+// In bash and zsh, the completion is registered only if the command is
+// found in $PATH when the rc file is loaded.
+func Lookup() Option {
+	return func(o *options) {
+		o.lookup = true
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) editor() *editor {
+	return &editor{dryRun: o.dryRun != nil}
+}
+
+// done finishes an installation that used the editor.
+func (o options) done(ed *editor) {
+	if o.dryRun != nil {
+		ed.print(o.dryRun)
+	}
 }
 
 // Install complete command given:
 // cmd: is the command name
-func Install(cmd string) error {
-	is := installers()
-	if len(is) == 0 {
-		return errors.New("Did not find any shells to install")
+func Install(cmd string, opts ...Option) error {
+	o := newOptions(opts)
+	bin, err := o.binaryPath(cmd)
+	if err != nil {
+		return err
+	}
+	if err := o.checkBinary(cmd, bin); err != nil {
+		return err
 	}
-	bin, err := getBinaryPath()
+	ed := o.editor()
+	defer o.done(ed)
+	return install(ed, o, cmd, bin)
+}
+
+func install(ed *editor, o options, cmd, bin string) error {
+	is, err := installers(ed, o)
 	if err != nil {
 		return err
 	}
+	if len(is) == 0 {
+		return errors.New("Did not find any shells to install")
+	}
 
 	for _, i := range is {
+		ed.shell = i.Name()
 		errI := i.Install(cmd, bin)
 		if errI != nil {
 			err = multierror.Append(err, errI)
@@ -40,13 +185,18 @@ func Install(cmd string) error {
 
 // IsInstalled returns true if the completion
 // for the given cmd is installed.
-func IsInstalled(cmd string) bool {
-	bin, err := getBinaryPath()
+func IsInstalled(cmd string, opts ...Option) bool {
+	o := newOptions(opts)
+	bin, err := o.binaryPath(cmd)
 	if err != nil {
 		return false
 	}
 
-	for _, i := range installers() {
+	is, err := installers(&editor{}, o)
+	if err != nil {
+		return false
+	}
+	for _, i := range is {
 		installed := i.IsInstalled(cmd, bin)
 		if installed {
 			return true
@@ -58,17 +208,28 @@ func IsInstalled(cmd string) bool {
 
 // Uninstall complete command given:
 // cmd: is the command name
-func Uninstall(cmd string) error {
-	is := installers()
-	if len(is) == 0 {
-		return errors.New("Did not find any shells to uninstall")
+func Uninstall(cmd string, opts ...Option) error {
+	o := newOptions(opts)
+	bin, err := o.binaryPath(cmd)
+	if err != nil {
+		return err
 	}
-	bin, err := getBinaryPath()
+	ed := o.editor()
+	defer o.done(ed)
+	return uninstall(ed, o, cmd, bin)
+}
+
+func uninstall(ed *editor, o options, cmd, bin string) error {
+	is, err := installers(ed, o)
 	if err != nil {
 		return err
 	}
+	if len(is) == 0 {
+		return errors.New("Did not find any shells to uninstall")
+	}
 
 	for _, i := range is {
+		ed.shell = i.Name()
 		errI := i.Uninstall(cmd, bin)
 		if errI != nil {
 			err = multierror.Append(err, errI)
@@ -78,9 +239,229 @@ func Uninstall(cmd string) error {
 	return err
 }
 
-func installers() (i []installer) {
-	// The list of bash config files candidates where it is
-	// possible to install the completion command.
+// installers returns the installers of the selected shells. Shells that were
+// not selected explicitly are skipped if their configuration was not found.
+func installers(ed *editor, o options) ([]installer, error) {
+	for _, name := range o.shells {
+		if !isShell(name) {
+			return nil, fmt.Errorf("unknown shell %q, supported shells: %v", name, shellNames)
+		}
+	}
+	for name := range o.targets {
+		if !isShell(name) {
+			return nil, fmt.Errorf("unknown shell %q, supported shells: %v", name, shellNames)
+		}
+	}
+
+	var is []installer
+	for _, name := range shellNames {
+		selected := o.selected(name)
+		if len(o.shells) > 0 && !selected {
+			continue
+		}
+		i, err := o.installer(ed, name)
+		if err != nil {
+			if selected {
+				return nil, err
+			}
+			continue
+		}
+		if i == nil {
+			if selected {
+				return nil, fmt.Errorf("did not find %s configuration, set a target to install in", name)
+			}
+			continue
+		}
+		is = append(is, i)
+	}
+	return is, nil
+}
+
+// installer returns the installer of a shell, that installs in the target of
+// the shell if it was given. It returns nil if the configuration of the
+// shell was not found.
+func (o options) installer(ed *editor, name string) (installer, error) {
+	target := o.targets[name]
+	if o.system {
+		return systemInstaller(ed, name, o.root, target)
+	}
+	if o.completionDir {
+		switch name {
+		case "bash":
+			if target == "" {
+				target = bashCompletionDir()
+			}
+			return bashCompletion{ed: ed, dir: target}, nil
+		case "zsh":
+			if target == "" {
+				target = zshCompletionDir()
+			}
+			if target == "" {
+				return nil, nil
+			}
+			return zshCompletion{ed: ed, dir: target}, nil
+		default:
+			return nil, fmt.Errorf("%s has no completion directory", name)
+		}
+	}
+
+	switch name {
+	case "bash":
+		if target == "" {
+			target = bashRC()
+		}
+		if target == "" {
+			return nil, nil
+		}
+		return bash{ed: ed, rc: target}, nil
+	case "zsh":
+		if target == "" {
+			target = rcFile(".zshrc")
+		}
+		if target == "" {
+			return nil, nil
+		}
+		return zsh{ed: ed, rc: target}, nil
+	case "fish":
+		if target == "" {
+			d := fishConfigDir()
+			if d == "" {
+				return nil, nil
+			}
+			target = filepath.Join(d, "completions")
+		}
+		return fish{ed: ed, dir: target}, nil
+	case "tcsh":
+		if target == "" {
+			target = rcFile(".tcshrc")
+		}
+		if target == "" {
+			return nil, nil
+		}
+		return tcsh{ed: ed, rc: target}, nil
+	case "pwsh":
+		if target == "" {
+			target = pwshProfile()
+		}
+		if target == "" {
+			return nil, nil
+		}
+		return pwsh{ed: ed, profile: target}, nil
+	case "nushell":
+		d := configDir("nushell")
+		if target == "" {
+			if d == "" {
+				return nil, nil
+			}
+			target = filepath.Join(d, "config.nu")
+		}
+		if d == "" {
+			d = filepath.Dir(target)
+		}
+		return nushell{ed: ed, configDir: d, rc: target}, nil
+	case "elvish":
+		d := elvishConfigDir()
+		if target == "" {
+			if d == "" {
+				return nil, nil
+			}
+			target = filepath.Join(d, "rc.elv")
+		}
+		if d == "" {
+			d = filepath.Dir(target)
+		}
+		return elvish{ed: ed, configDir: d, rc: target}, nil
+	case "xonsh":
+		if target == "" {
+			if configDir("xonsh") == "" && rcFile(".xonshrc") == "" {
+				return nil, nil
+			}
+			target = xonshRC()
+		}
+		return xonsh{ed: ed, configDir: filepath.Join(getConfigHomePath(), "xonsh"), rc: target}, nil
+	}
+	return nil, nil
+}
+
+// systemInstaller returns the installer of a shell in its system completion
+// directory under root.
+func systemInstaller(ed *editor, name, root, target string) (installer, error) {
+	dir, ok := systemCompletionDirs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s has no system completion directory", name)
+	}
+	if target == "" {
+		target = filepath.Join(root, dir)
+	}
+	switch name {
+	case "bash":
+		return bashCompletion{ed: ed, dir: target}, nil
+	case "zsh":
+		return zshCompletion{ed: ed, dir: target}, nil
+	default:
+		return fish{ed: ed, dir: target}, nil
+	}
+}
+
+// systemCompletionDirs are the directories of the system wide completion
+// files that are installed by distribution packages, by shell name.
+var systemCompletionDirs = map[string]string{
+	"bash": "/usr/share/bash-completion/completions",
+	"zsh":  "/usr/share/zsh/site-functions",
+	"fish": "/usr/share/fish/vendor_completions.d",
+}
+
+// binaryPath returns the path of the binary that the completion runs, or the
+// command name in lookup mode.
+func (o options) binaryPath(cmd string) (string, error) {
+	if o.lookup {
+		return cmd, nil
+	}
+	bin, err := getBinaryPath(cmd)
+	if err != nil || !o.system || o.root == "" {
+		return bin, err
+	}
+	root, err := filepath.Abs(o.root)
+	if err != nil {
+		return "", err
+	}
+	if within(root, bin) {
+		rel, _ := filepath.Rel(root, bin)
+		return filepath.Join("/", rel), nil
+	}
+	return bin, nil
+}
+
+// checkBinary returns an error if the completion would run a binary that is
+// about to disappear, such as a binary that was built by go run.
+func (o options) checkBinary(cmd, bin string) error {
+	if o.lookup || !isTemporary(bin) {
+		return nil
+	}
+	return fmt.Errorf("%s is a temporary build of %s, install it in $PATH first, or install completion with a $PATH lookup", bin, cmd)
+}
+
+func (o options) selected(name string) bool {
+	for _, s := range o.shells {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isShell(name string) bool {
+	for _, s := range shellNames {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// bashRC returns the first bash config file that exists, where it is
+// possible to install the completion command.
+func bashRC() string {
 	var bashConfFiles []string
 	switch runtime.GOOS {
 	case "darwin":
@@ -90,24 +471,95 @@ func installers() (i []installer) {
 	}
 	for _, rc := range bashConfFiles {
 		if f := rcFile(rc); f != "" {
-			i = append(i, bash{f})
-			break
+			return f
+		}
+	}
+	return ""
+}
+
+// bashCompletionDir returns the bash-completion user completions directory.
+func bashCompletionDir() string {
+	if d := os.Getenv("BASH_COMPLETION_USER_DIR"); d != "" {
+		return filepath.Join(d, "completions")
+	}
+	return filepath.Join(getDataHomePath(), "bash-completion", "completions")
+}
+
+// zshCompletionDir returns the first directory in the zsh fpath, as
+// exported in the FPATH environment variable, that is in the home directory
+// of the user. zsh does not export FPATH by default, so for users of zsh,
+// that have a ~/.zshrc, it falls back to $XDG_DATA_HOME/zsh/site-functions,
+// which the user should add to the fpath.
+func zshCompletionDir() string {
+	home := homeDir()
+	if home == "" {
+		return ""
+	}
+	for _, d := range filepath.SplitList(os.Getenv("FPATH")) {
+		if !within(home, d) {
+			continue
+		}
+		if info, err := os.Stat(d); err == nil && info.IsDir() {
+			return d
 		}
 	}
-	if f := rcFile(".zshrc"); f != "" {
-		i = append(i, zsh{f})
+	if rcFile(".zshrc") == "" {
+		return ""
 	}
-	if d := fishConfigDir(); d != "" {
-		i = append(i, fish{d})
+	return filepath.Join(getDataHomePath(), "zsh", "site-functions")
+}
+
+// within returns true if path is in the directory dir.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// pwshProfile returns the path of the PowerShell profile of the current
+// user, if PowerShell is configured. The profile file itself may not exist.
+func pwshProfile() string {
+	configDir := filepath.Join(getConfigHomePath(), "powershell")
+	if info, err := os.Stat(configDir); err != nil || !info.IsDir() {
+		return ""
 	}
-	return
+	return filepath.Join(configDir, "Microsoft.PowerShell_profile.ps1")
 }
 
 func fishConfigDir() string {
-	configDir := filepath.Join(getConfigHomePath(), "fish")
-	if configDir == "" {
+	return configDir("fish")
+}
+
+// elvishConfigDir returns the elvish configuration directory, or the legacy
+// ~/.elvish directory of older elvish versions.
+func elvishConfigDir() string {
+	if d := configDir("elvish"); d != "" {
+		return d
+	}
+	if d := rcFile(".elvish"); d != "" {
+		if info, err := os.Stat(d); err == nil && info.IsDir() {
+			return d
+		}
+	}
+	return ""
+}
+
+// xonshRC returns the xonsh rc file, ~/.xonshrc if it exists, or rc.xsh in
+// the xonsh configuration directory.
+func xonshRC() string {
+	if f := rcFile(".xonshrc"); f != "" {
+		return f
+	}
+	return filepath.Join(getConfigHomePath(), "xonsh", "rc.xsh")
+}
+
+// configDir returns the configuration directory of the given shell,
+// if it exists.
+func configDir(name string) string {
+	configHome := getConfigHomePath()
+	if configHome == "" {
 		return ""
 	}
+	configDir := filepath.Join(configHome, name)
 	if info, err := os.Stat(configDir); err != nil || !info.IsDir() {
 		return ""
 	}
@@ -115,34 +567,95 @@ func fishConfigDir() string {
 }
 
 func getConfigHomePath() string {
-	u, err := user.Current()
-	if err != nil {
+	home := homeDir()
+	if home == "" {
 		return ""
 	}
 
 	configHome := os.Getenv("XDG_CONFIG_HOME")
 	if configHome == "" {
-		return filepath.Join(u.HomeDir, ".config")
+		return filepath.Join(home, ".config")
 	}
 	return configHome
 }
 
-func getBinaryPath() (string, error) {
+func getDataHomePath() string {
+	home := homeDir()
+	if home == "" {
+		return ""
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		return filepath.Join(home, ".local", "share")
+	}
+	return dataHome
+}
+
+// getBinaryPath returns the path of the running binary. If the binary is
+// the cmd command in $PATH, the path in $PATH is preferred over the resolved
+// path of the binary, so the completion keeps working when a symlink in
+// $PATH is pointed to a new version of the binary.
+func getBinaryPath(cmd string) (string, error) {
 	bin, err := os.Executable()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Abs(bin)
+	bin, err = filepath.Abs(bin)
+	if err != nil {
+		return "", err
+	}
+	if path, err := exec.LookPath(cmd); err == nil && sameFile(path, bin) {
+		if path, err := filepath.Abs(path); err == nil {
+			return path, nil
+		}
+	}
+	return bin, nil
 }
 
-func rcFile(name string) string {
-	u, err := user.Current()
+// isTemporary returns true if the binary is in a temporary directory, or in
+// the go build cache, where go run builds binaries.
+func isTemporary(bin string) bool {
+	if within(os.TempDir(), bin) {
+		return true
+	}
+	if cache := os.Getenv("GOCACHE"); cache != "" && within(cache, bin) {
+		return true
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(bin), "/") {
+		if strings.HasPrefix(elem, "go-build") {
+			return true
+		}
+	}
+	return false
+}
+
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
 	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	return err == nil && os.SameFile(aInfo, bInfo)
+}
+
+func rcFile(name string) string {
+	home := homeDir()
+	if home == "" {
 		return ""
 	}
-	path := filepath.Join(u.HomeDir, name)
+	path := filepath.Join(home, name)
 	if _, err := os.Stat(path); err != nil {
 		return ""
 	}
 	return path
 }
+
+// homeDir returns the home directory of the user, from the user database.
+func homeDir() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.HomeDir
+}
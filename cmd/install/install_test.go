@@ -0,0 +1,218 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallers_Selection(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rc := filepath.Join(dir, "rc")
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    []installer
+		wantErr bool
+	}{
+		{
+			name: "target",
+			opts: []Option{Shells("bash"), Target("bash", rc)},
+			want: []installer{bash{rc: rc}},
+		},
+		{
+			name: "several targets",
+			opts: []Option{Shells("zsh", "tcsh"), Target("zsh", rc), Target("tcsh", rc)},
+			want: []installer{zsh{rc: rc}, tcsh{rc: rc}},
+		},
+		{
+			name: "completion dir",
+			opts: []Option{Shells("bash", "zsh"), CompletionDir(), Target("bash", dir), Target("zsh", dir)},
+			want: []installer{bashCompletion{dir: dir}, zshCompletion{dir: dir}},
+		},
+		{
+			name:    "completion dir unsupported shell",
+			opts:    []Option{Shells("fish"), CompletionDir(), Target("fish", dir)},
+			wantErr: true,
+		},
+		{
+			name: "system",
+			opts: []Option{System(dir)},
+			want: []installer{
+				bashCompletion{dir: filepath.Join(dir, "usr/share/bash-completion/completions")},
+				zshCompletion{dir: filepath.Join(dir, "usr/share/zsh/site-functions")},
+				fish{dir: filepath.Join(dir, "usr/share/fish/vendor_completions.d")},
+			},
+		},
+		{
+			name: "system target",
+			opts: []Option{System(""), Shells("zsh"), Target("zsh", dir)},
+			want: []installer{zshCompletion{dir: dir}},
+		},
+		{
+			name:    "system unsupported shell",
+			opts:    []Option{System(dir), Shells("tcsh")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown shell",
+			opts:    []Option{Shells("cmd.exe")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown target shell",
+			opts:    []Option{Target("cmd.exe", rc)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is, err := installers(nil, newOptions(tt.opts))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, is)
+		})
+	}
+}
+
+func TestCompletionDir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ed := &editor{}
+	b := bashCompletion{ed: ed, dir: filepath.Join(dir, "bash")}
+	z := zshCompletion{ed: ed, dir: filepath.Join(dir, "zsh")}
+
+	require.NoError(t, b.Install("cmd", "/bin/cmd"))
+	require.NoError(t, z.Install("cmd", "/bin/cmd"))
+	assertFile(t, filepath.Join(dir, "bash", "cmd"), "complete -C /bin/cmd cmd\n")
+	assertFile(t, filepath.Join(dir, "zsh", "_cmd"), z.cmd("cmd", "/bin/cmd")+"\n")
+	assert.True(t, b.IsInstalled("cmd", "/bin/cmd"))
+	assert.True(t, z.IsInstalled("cmd", "/bin/cmd"))
+	assert.Error(t, b.Install("cmd", "/bin/cmd"), "already installed")
+
+	require.NoError(t, b.Uninstall("cmd", "/bin/cmd"))
+	require.NoError(t, z.Uninstall("cmd", "/bin/cmd"))
+	assert.False(t, b.IsInstalled("cmd", "/bin/cmd"))
+	assert.False(t, z.IsInstalled("cmd", "/bin/cmd"))
+	assert.Error(t, b.Uninstall("cmd", "/bin/cmd"))
+}
+
+func TestBashRC_MovedBinary(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The rc file has the complete command of an older version, with the
+	// path that the binary had before it was moved.
+	rc := filepath.Join(dir, ".bashrc")
+	require.NoError(t, ioutil.WriteFile(rc, []byte("export A=1\ncomplete -C /old/bin/cmd cmd\ncomplete -C /old/bin/other other\n"), 0644))
+
+	b := bash{ed: &editor{}, rc: rc}
+	assert.True(t, b.IsInstalled("cmd", "/new/bin/cmd"))
+	require.NoError(t, b.Install("cmd", "/new/bin/cmd"))
+	assertFile(t, rc, "export A=1\ncomplete -C /old/bin/other other\n\n"+
+		"# BEGIN complete: cmd\ncomplete -C /new/bin/cmd cmd\n# END complete: cmd\n")
+
+	require.NoError(t, ioutil.WriteFile(rc, []byte("complete -C '/old path/cmd' cmd\n"), 0644))
+	require.NoError(t, b.Uninstall("cmd", "/new/bin/cmd"))
+	assertFile(t, rc, "")
+}
+
+func TestZshRC(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// An rc file with the complete command of older versions.
+	rc := filepath.Join(dir, ".zshrc")
+	require.NoError(t, ioutil.WriteFile(rc, []byte("export A=1\ncomplete -o nospace -C /old/bin/cmd cmd\n"), 0644))
+
+	z := zsh{ed: &editor{}, rc: rc}
+	require.NoError(t, z.Install("cmd", "/bin/cmd"))
+	data, err := ioutil.ReadFile(rc)
+	require.NoError(t, err)
+	content := string(data)
+
+	// The completion function gets the hints, that are not passed through the
+	// bash compatible complete command.
+	assert.Contains(t, content, "COMP_SHELL=zsh /bin/cmd)")
+	assert.Contains(t, content, "_message -r")
+	assert.Contains(t, content, "\ncompdef _complete_cmd cmd\n")
+	assert.NotContains(t, content, "complete -o nospace -C")
+	assert.True(t, z.IsInstalled("cmd", "/bin/cmd"))
+
+	require.NoError(t, z.Uninstall("cmd", "/bin/cmd"))
+	assertFile(t, rc, "export A=1\n")
+}
+
+func TestOptions_BinaryPath(t *testing.T) {
+	t.Parallel()
+
+	bin, err := getBinaryPath("cmd")
+	require.NoError(t, err)
+
+	got, err := newOptions(nil).binaryPath("cmd")
+	require.NoError(t, err)
+	assert.Equal(t, bin, got)
+
+	got, err = newOptions([]Option{System(filepath.Dir(bin))}).binaryPath("cmd")
+	require.NoError(t, err)
+	assert.Equal(t, "/"+filepath.Base(bin), got)
+
+	got, err = newOptions([]Option{System("/nonexistent")}).binaryPath("cmd")
+	require.NoError(t, err)
+	assert.Equal(t, bin, got)
+}
+
+func TestOptions_Lookup(t *testing.T) {
+	t.Parallel()
+
+	o := newOptions([]Option{Lookup()})
+	got, err := o.binaryPath("cmd")
+	require.NoError(t, err)
+	assert.Equal(t, "cmd", got)
+	assert.NoError(t, o.checkBinary("cmd", got))
+
+	assert.Equal(t,
+		`if command -v cmd >/dev/null 2>&1; then complete -C "$(command -v cmd)" cmd; fi`,
+		bash{}.cmd("cmd", "cmd"))
+	zshCmd := zsh{}.cmd("cmd", "cmd")
+	assert.Contains(t, zshCmd, "COMP_SHELL=zsh cmd)")
+	assert.True(t, strings.HasSuffix(zshCmd,
+		"\nif command -v cmd >/dev/null 2>&1; then compdef _complete_cmd cmd; fi"), zshCmd)
+}
+
+func TestIsTemporary(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isTemporary(filepath.Join(os.TempDir(), "go-build123", "b001", "exe", "cmd")))
+	assert.True(t, isTemporary("/home/user/.cache/go-build/ab/cmd"))
+	assert.False(t, isTemporary("/home/user/go/bin/cmd"))
+	assert.False(t, isTemporary("/usr/bin/cmd"))
+
+	assert.Error(t, newOptions(nil).checkBinary("cmd", filepath.Join(os.TempDir(), "cmd")))
+	assert.NoError(t, newOptions(nil).checkBinary("cmd", "/usr/bin/cmd"))
+}
@@ -0,0 +1,92 @@
+package install
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// (un)install in nushell
+// basically creates a completion script that sets an external completer that
+// runs the completion command, and adds/remove from config.nu a block with
+// a line that loads it:
+//
+// source <config dir>/completions/<command>.nu
+//
+// The external completer is shared by all commands, so the script chains
+// the completer that was set before it for other commands.
+type nushell struct {
+	ed        *editor
+	configDir string
+	rc        string
+}
+
+func (nushell) Name() string {
+	return "nushell"
+}
+
+func (n nushell) IsInstalled(cmd, bin string) bool {
+	return n.block(cmd).isInstalled()
+}
+
+func (n nushell) Install(cmd, bin string) error {
+	content, err := n.script(cmd, bin)
+	if err != nil {
+		return err
+	}
+	return n.ed.installScript(n.block(cmd), n.hook(cmd), n.scriptPath(cmd), content)
+}
+
+func (n nushell) Uninstall(cmd, bin string) error {
+	return n.ed.uninstallScript(n.block(cmd), n.scriptPath(cmd))
+}
+
+func (n nushell) block(cmd string) rcBlock {
+	return rcBlock{ed: n.ed, rc: n.rc, cmd: cmd}
+}
+
+func (n nushell) scriptPath(cmd string) string {
+	return filepath.Join(n.configDir, "completions", cmd+".nu")
+}
+
+func (n nushell) hook(cmd string) string {
+	return "source " + nuQuote(n.scriptPath(cmd))
+}
+
+func (nushell) script(cmd, bin string) (string, error) {
+	var buf bytes.Buffer
+	params := struct{ Cmd, Bin string }{nuQuote(cmd), nuQuote(bin)}
+	tmpl := template.Must(template.New("nushell").Parse(`
+$env.config.completions.external.enable = true
+$env.config.completions.external.completer = do {
+    let previous = $env.config.completions.external.completer
+    {|spans|
+        if ($spans | first) == {{.Cmd}} {
+            let line = ($spans | str join ' ')
+            with-env {COMP_LINE: $line, COMP_POINT: ($line | str length | into string), COMP_SHELL: nu} {
+                ^{{.Bin}} | lines
+            }
+        } else if $previous != null {
+            do $previous $spans
+        }
+    }
+}
+`))
+	err := tmpl.Execute(&buf, params)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// nuQuote quotes s as a nushell double quoted string.
+func nuQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func (n nushell) completion(cmd, bin string) (string, string, error) {
+	content, err := n.script(cmd, bin)
+	return n.scriptPath(cmd), content, err
+}
@@ -0,0 +1,88 @@
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// (un)install in PowerShell
+// basically adds/remove from the PowerShell profile a block with a native
+// argument completer that runs the completion command:
+//
+// Register-ArgumentCompleter -Native -CommandName <command> -ScriptBlock { ... }
+//
+// The script block translates the command AST and the cursor position to
+// the COMP_LINE and COMP_POINT environment variables, and sets COMP_SHELL to
+// "pwsh" so the completion command outputs each match as the completion
+// text and the list item text, separated by a tab.
+type pwsh struct {
+	ed      *editor
+	profile string
+}
+
+func (pwsh) Name() string {
+	return "pwsh"
+}
+
+func (p pwsh) IsInstalled(cmd, bin string) bool {
+	return p.block(cmd).isInstalled()
+}
+
+func (p pwsh) Install(cmd, bin string) error {
+	completeCmd, err := p.cmd(cmd, bin)
+	if err != nil {
+		return err
+	}
+	block := p.block(cmd)
+	if block.has(completeCmd) {
+		return fmt.Errorf("already installed in %s", p.profile)
+	}
+	return block.install(completeCmd)
+}
+
+func (p pwsh) Uninstall(cmd, bin string) error {
+	return p.block(cmd).uninstall()
+}
+
+func (p pwsh) block(cmd string) rcBlock {
+	return rcBlock{ed: p.ed, rc: p.profile, cmd: cmd}
+}
+
+func (pwsh) cmd(cmd, bin string) (string, error) {
+	var buf bytes.Buffer
+	params := struct{ Cmd, Bin string }{pwshQuote(cmd), pwshQuote(bin)}
+	tmpl := template.Must(template.New("pwsh").Parse(`Register-ArgumentCompleter -Native -CommandName {{.Cmd}} -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $line = $commandAst.Extent.Text
+    $point = $cursorPosition - $commandAst.Extent.StartOffset
+    if ($point -gt $line.Length) { $line = $line.PadRight($point) }
+    $line = $line.Substring(0, $point)
+    $env:COMP_LINE = $line
+    $env:COMP_POINT = [System.Text.Encoding]::UTF8.GetByteCount($line)
+    $env:COMP_SHELL = 'pwsh'
+    & {{.Bin}} | ForEach-Object {
+        $text, $item = $_ -split "` + "`" + `t", 2
+        if (-not $item) { $item = $text }
+        $type = if ($item.StartsWith('-')) { 'ParameterName' } else { 'ParameterValue' }
+        [System.Management.Automation.CompletionResult]::new($text, $item, $type, $item)
+    }
+    Remove-Item Env:COMP_LINE, Env:COMP_POINT, Env:COMP_SHELL
+}`))
+	err := tmpl.Execute(&buf, params)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// pwshQuote quotes s as a PowerShell verbatim string.
+func pwshQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func (p pwsh) completion(cmd, bin string) (string, string, error) {
+	content, err := p.cmd(cmd, bin)
+	return p.profile, content, err
+}
@@ -0,0 +1,98 @@
+package install
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// InstallShim installs completion for cmd through a client shim script.
+// The shim forwards completion requests to the completion daemon listening
+// on the Unix socket in socket, see the daemon package, and runs the
+// completion binary when the daemon is down.
+func InstallShim(cmd, socket string, opts ...Option) error {
+	o := newOptions(opts)
+	bin, err := o.binaryPath(cmd)
+	if err != nil {
+		return err
+	}
+	if err := o.checkBinary(cmd, bin); err != nil {
+		return err
+	}
+	ed := o.editor()
+	defer o.done(ed)
+	shim := shimPath(cmd)
+	if shim == "" {
+		return errors.New("could not find a directory for the shim script")
+	}
+	content, err := shimScript(bin, socket)
+	if err != nil {
+		return err
+	}
+	if err := ed.createFile(shim, content); err != nil {
+		return err
+	}
+	if err := ed.chmod(shim, 0755); err != nil {
+		return err
+	}
+	return install(ed, o, cmd, shim)
+}
+
+// UninstallShim uninstalls completion for cmd that was installed with
+// InstallShim, and removes the shim script.
+func UninstallShim(cmd string, opts ...Option) error {
+	shim := shimPath(cmd)
+	if shim == "" {
+		return errors.New("could not find a directory for the shim script")
+	}
+	o := newOptions(opts)
+	ed := o.editor()
+	defer o.done(ed)
+	err := uninstall(ed, o, cmd, shim)
+	if errRm := ed.remove(shim); errRm != nil {
+		return fmt.Errorf("failed removing shim: %v", errRm)
+	}
+	return err
+}
+
+func shimPath(cmd string) string {
+	dataHome := getDataHomePath()
+	if dataHome == "" {
+		return ""
+	}
+	return filepath.Join(dataHome, "complete", cmd+"-complete.sh")
+}
+
+func shimScript(bin, socket string) (string, error) {
+	var buf bytes.Buffer
+	params := struct{ Bin, Socket string }{shellQuote(bin), shellQuote(socket)}
+	tmpl := template.Must(template.New("shim").Parse(`#!/bin/sh
+# Completion client generated by github.com/posener/complete.
+# It forwards the completion request to the completion daemon, and runs the
+# completion binary when the daemon is not available.
+# The socket and its directory must be owned by the user, otherwise another
+# user could answer the completion requests.
+# tcsh passes the line in COMMAND_LINE, without a cursor position, the daemon
+# completes the whole line when the position is empty.
+socket={{.Socket}}
+if [ -S "$socket" ] && [ -O "$socket" ] && [ -O "$(dirname "$socket")" ] &&
+	[ ! -L "$(dirname "$socket")" ] && command -v nc >/dev/null 2>&1; then
+	printf '%s\n%s\n%s\n%s\n' "$COMP_POINT" "$PWD" "$COMP_SHELL" \
+		"${COMP_LINE:-$COMMAND_LINE}" |
+		nc -U "$socket" 2>/dev/null && exit 0
+fi
+exec {{.Bin}} "$@"`))
+	err := tmpl.Execute(&buf, params)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,146 @@
+package install
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ShellStatus is the status of the completion of a command in a shell.
+type ShellStatus struct {
+	// Shell is the name of the shell.
+	Shell string
+	// Installed is true if the completion is installed.
+	Installed bool
+	// File is the file that the completion command is installed in, or
+	// would be installed in.
+	File string
+	// Binary is the path of the binary that the installed completion runs.
+	// It is empty if it was not found in File.
+	Binary string
+	// BinaryExists is true if Binary exists.
+	BinaryExists bool
+	// Current is true if Binary is the running executable.
+	Current bool
+}
+
+// binaryPlaceholder is written instead of the binary path to find where the
+// installers write the binary path.
+const binaryPlaceholder = "/COMPLETE-BINARY-PLACEHOLDER"
+
+// Status returns the status of the completion of cmd in the detected, or
+// selected, shells. It can be used to diagnose completion that does not
+// work, for example because it runs a binary that was moved or rebuilt.
+func Status(cmd string, opts ...Option) ([]ShellStatus, error) {
+	o := newOptions(opts)
+	// The installed completion is compared with the running binary, also
+	// when it is installed with a $PATH lookup.
+	o.lookup = false
+	bin, err := o.binaryPath(cmd)
+	if err != nil {
+		return nil, err
+	}
+	ed := &editor{}
+	is, err := installers(ed, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []ShellStatus
+	for _, i := range is {
+		s := ShellStatus{Shell: i.Name(), Installed: i.IsInstalled(cmd, bin)}
+		file, content, err := i.completion(cmd, binaryPlaceholder)
+		if err != nil {
+			return nil, err
+		}
+		s.File = file
+		if s.Installed {
+			s.Binary = findBinary(ed, file, content)
+		}
+		if s.Installed && s.Binary == "" && hasLookup(ed, i, cmd) {
+			s.Binary = cmd
+		}
+		if s.Binary != "" {
+			s.BinaryExists, s.Current = binaryStatus(s.Binary, bin)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// findBinary returns the binary path in a file that the completion command
+// was written to. content is the completion command, as written with the
+// binary placeholder. The text before the placeholder is looked up in the
+// file, and the binary path is taken from the rest of that line, up to the
+// text that follows the placeholder in the line.
+func findBinary(ed *editor, file, content string) string {
+	data, err := ed.read(file)
+	if err != nil {
+		return ""
+	}
+	i := strings.Index(content, binaryPlaceholder)
+	if i < 0 {
+		return ""
+	}
+	prefix, suffix := content[:i], content[i+len(binaryPlaceholder):]
+	if j := strings.Index(suffix, "\n"); j >= 0 {
+		suffix = suffix[:j]
+	}
+
+	text := string(data)
+	for {
+		i := strings.Index(text, prefix)
+		if i < 0 {
+			return ""
+		}
+		text = text[i+len(prefix):]
+		line := text
+		if j := strings.Index(line, "\n"); j >= 0 {
+			line = line[:j]
+		}
+		if strings.HasSuffix(line, suffix) && len(line) > len(suffix) {
+			return unquote(strings.TrimSuffix(line, suffix))
+		}
+		if prefix == "" {
+			return ""
+		}
+	}
+}
+
+// hasLookup returns true if the completion is installed with a $PATH
+// lookup of the command.
+func hasLookup(ed *editor, i installer, cmd string) bool {
+	file, content, err := i.completion(cmd, cmd)
+	if err != nil {
+		return false
+	}
+	data, err := ed.read(file)
+	return err == nil && strings.Contains(string(data), content)
+}
+
+// unquote removes the quotes that the installers put around the binary
+// path. Escaped characters in the path are kept as is.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// binaryStatus returns if the binary exists, and if it is the same file as
+// the running binary.
+// A binary that is a command name is looked up in $PATH.
+func binaryStatus(bin, current string) (exists, same bool) {
+	if !filepath.IsAbs(bin) {
+		path, err := exec.LookPath(bin)
+		if err != nil {
+			return false, false
+		}
+		bin = path
+	}
+	if _, err := os.Stat(bin); err != nil {
+		return false, false
+	}
+	return true, bin == current || sameFile(bin, current)
+}
@@ -0,0 +1,80 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bin, err := getBinaryPath("cmd")
+	require.NoError(t, err)
+	rc := filepath.Join(dir, ".bashrc")
+	opts := []Option{Shells("bash", "zsh"), Target("bash", rc), Target("zsh", filepath.Join(dir, ".zshrc"))}
+
+	got, err := Status("cmd", opts...)
+	require.NoError(t, err)
+	assert.Equal(t, []ShellStatus{
+		{Shell: "bash", File: rc},
+		{Shell: "zsh", File: filepath.Join(dir, ".zshrc")},
+	}, got)
+
+	require.NoError(t, ioutil.WriteFile(rc, []byte("complete -C /bin/other other\n"), 0644))
+	require.NoError(t, bash{ed: &editor{}, rc: rc}.Install("cmd", "/nonexistent/cmd"))
+	got, err = Status("cmd", opts...)
+	require.NoError(t, err)
+	assert.Equal(t, ShellStatus{Shell: "bash", Installed: true, File: rc, Binary: "/nonexistent/cmd"}, got[0])
+
+	require.NoError(t, bash{ed: &editor{}, rc: rc}.Install("cmd", bin))
+	got, err = Status("cmd", opts...)
+	require.NoError(t, err)
+	assert.Equal(t, ShellStatus{Shell: "bash", Installed: true, File: rc, Binary: bin, BinaryExists: true, Current: true}, got[0])
+
+	require.NoError(t, bash{ed: &editor{}, rc: rc}.Install("cmd", "cmd"))
+	got, err = Status("cmd", append(opts, Lookup())...)
+	require.NoError(t, err)
+	assert.Equal(t, ShellStatus{Shell: "bash", Installed: true, File: rc, Binary: "cmd"}, got[0])
+}
+
+func TestFindBinary(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		i    installer
+	}{
+		{name: "fish", i: fish{dir: dir}},
+		{name: "tcsh", i: tcsh{rc: filepath.Join(dir, "tcshrc")}},
+		{name: "pwsh", i: pwsh{profile: filepath.Join(dir, "profile.ps1")}},
+		{name: "nushell", i: nushell{configDir: dir, rc: filepath.Join(dir, "config.nu")}},
+		{name: "elvish", i: elvish{configDir: dir, rc: filepath.Join(dir, "rc.elv")}},
+		{name: "xonsh", i: xonsh{configDir: dir, rc: filepath.Join(dir, "rc.xsh")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ed := &editor{dryRun: true}
+			file, content, err := tt.i.completion("cmd", "/usr/bin/cmd")
+			require.NoError(t, err)
+			require.NoError(t, ed.createFile(file, content))
+
+			_, content, err = tt.i.completion("cmd", binaryPlaceholder)
+			require.NoError(t, err)
+			assert.Equal(t, "/usr/bin/cmd", findBinary(ed, file, content))
+		})
+	}
+}
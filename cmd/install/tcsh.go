@@ -0,0 +1,48 @@
+package install
+
+import "fmt"
+
+// (un)install in tcsh
+// basically adds/remove from .tcshrc a block with:
+//
+// complete <command> 'p/*/`env COMP_SHELL=tcsh </path/to/completion/command>`/'
+//
+// tcsh does not set COMP_LINE, but sets COMMAND_LINE to the completed line
+// when it runs the completion command. COMP_SHELL tells the completion
+// command to read it.
+type tcsh struct {
+	ed *editor
+	rc string
+}
+
+func (tcsh) Name() string {
+	return "tcsh"
+}
+
+func (t tcsh) IsInstalled(cmd, bin string) bool {
+	return t.block(cmd).isInstalled()
+}
+
+func (t tcsh) Install(cmd, bin string) error {
+	block, completeCmd := t.block(cmd), t.cmd(cmd, bin)
+	if block.has(completeCmd) {
+		return fmt.Errorf("already installed in %s", t.rc)
+	}
+	return block.install(completeCmd)
+}
+
+func (t tcsh) Uninstall(cmd, bin string) error {
+	return t.block(cmd).uninstall()
+}
+
+func (t tcsh) block(cmd string) rcBlock {
+	return rcBlock{ed: t.ed, rc: t.rc, cmd: cmd}
+}
+
+func (tcsh) cmd(cmd, bin string) string {
+	return fmt.Sprintf("complete %s 'p/*/`env COMP_SHELL=tcsh %s`/'", cmd, bin)
+}
+
+func (t tcsh) completion(cmd, bin string) (string, string, error) {
+	return t.rc, t.cmd(cmd, bin), nil
+}
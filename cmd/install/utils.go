@@ -1,140 +1,135 @@
 package install
 
 import (
-	"bufio"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 )
 
-func lineInFile(name string, lookFor string) bool {
-	f, err := os.Open(name)
+// backupFile copies a file to a new timestamped backup file next to it,
+// "<file>.<time>.bck", and returns the backup file name. If the file does not
+// exist, no backup is made and an empty name is returned. The backup is
+// created exclusively, so an existing file, such as an earlier backup, is
+// never overwritten.
+func backupFile(name string) (string, error) {
+	path, err := realPath(name)
 	if err != nil {
-		return false
-	}
-	defer f.Close()
-	r := bufio.NewReader(f)
-	prefix := []byte{}
-	for {
-		line, isPrefix, err := r.ReadLine()
-		if err == io.EOF {
-			return false
-		}
-		if err != nil {
-			return false
-		}
-		if isPrefix {
-			prefix = append(prefix, line...)
-			continue
-		}
-		line = append(prefix, line...)
-		if string(line) == lookFor {
-			return true
-		}
-		prefix = prefix[:0]
+		return "", err
 	}
-}
-
-func createFile(name string, content string) error {
-	// make sure file directory exists
-	if err := os.MkdirAll(filepath.Dir(name), 0775); err != nil {
-		return err
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", nil
 	}
-
-	// create the file
-	f, err := os.Create(name)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer f.Close()
-
-	// write file content
-	_, err = f.WriteString(fmt.Sprintf("%s\n", content))
-	return err
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	stamp := time.Now().Format("20060102-150405.000")
+	backup := fmt.Sprintf("%s.%s.bck", path, stamp)
+	f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	// Backups in the same millisecond get a counter.
+	for i := 1; os.IsExist(err) && i < maxBackups; i++ {
+		backup = fmt.Sprintf("%s.%s-%d.bck", path, stamp, i)
+		f, err = os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	}
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	return backup, err
 }
 
-func appendToFile(name string, content string) error {
-	f, err := os.OpenFile(name, os.O_RDWR|os.O_APPEND, 0)
+// maxBackups limits the number of backups of a file in the same millisecond.
+const maxBackups = 100
+
+// writeFile atomically replaces the content of a file: the content is
+// written to a temporary file in the same directory, synced and renamed over
+// the file. Symbolic links are followed, so the file they point to is
+// replaced and the links are kept. The permissions of an existing file are
+// preserved.
+func writeFile(name string, content string) error {
+	path, err := realPath(name)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = f.WriteString(fmt.Sprintf("\n%s\n", content))
-	return err
-}
 
-func removeFromFile(name string, content string) error {
-	backup := name + ".bck"
-	err := copyFile(name, backup)
-	if err != nil {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
 		return err
 	}
-	temp, err := removeContentToTempFile(name, content)
+
+	dir := filepath.Dir(path)
+	temp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp-")
 	if err != nil {
 		return err
 	}
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(temp.Name())
+		}
+	}()
 
-	err = copyFile(temp, name)
+	_, err = temp.WriteString(content)
+	if err == nil {
+		err = temp.Chmod(mode)
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if errClose := temp.Close(); err == nil {
+		err = errClose
+	}
 	if err != nil {
 		return err
 	}
 
-	return os.Remove(backup)
-}
-
-func removeContentToTempFile(name, content string) (string, error) {
-	rf, err := os.Open(name)
-	if err != nil {
-		return "", err
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
 	}
-	defer rf.Close()
-	wf, err := ioutil.TempFile("/tmp", "complete-")
-	if err != nil {
-		return "", err
+	renamed = true
+
+	// Sync the directory so the rename is persisted.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
 	}
-	defer wf.Close()
+	return nil
+}
 
-	r := bufio.NewReader(rf)
-	prefix := []byte{}
-	for {
-		line, isPrefix, err := r.ReadLine()
-		if err == io.EOF {
-			break
+// realPath follows the symbolic links in name, and returns the path of the
+// file they point to, which may not exist.
+func realPath(name string) (string, error) {
+	const maxLinks = 255
+	for i := 0; i < maxLinks; i++ {
+		info, err := os.Lstat(name)
+		if os.IsNotExist(err) {
+			return name, nil
 		}
 		if err != nil {
 			return "", err
 		}
-		if isPrefix {
-			prefix = append(prefix, line...)
-			continue
-		}
-		line = append(prefix, line...)
-		str := string(line)
-		if str == content {
-			continue
+		if info.Mode()&os.ModeSymlink == 0 {
+			return name, nil
 		}
-		_, err = wf.WriteString(str + "\n")
+		link, err := os.Readlink(name)
 		if err != nil {
 			return "", err
 		}
-		prefix = prefix[:0]
-	}
-	return wf.Name(), nil
-}
-
-func copyFile(src string, dst string) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(name), link)
+		}
+		name = link
 	}
-	defer out.Close()
-	_, err = io.Copy(out, in)
-	return err
+	return "", fmt.Errorf("too many levels of symbolic links in %s", name)
 }
@@ -0,0 +1,165 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The rc file is a symbolic link to a file in a dotfiles directory.
+	dotfiles := filepath.Join(dir, "dotfiles")
+	require.NoError(t, os.Mkdir(dotfiles, 0755))
+	real := filepath.Join(dotfiles, "bashrc")
+	require.NoError(t, ioutil.WriteFile(real, []byte("a\nb\n"), 0600))
+	rc := filepath.Join(dir, ".bashrc")
+	require.NoError(t, os.Symlink(filepath.Join("dotfiles", "bashrc"), rc))
+
+	err = (&editor{}).rewriteFile(rc, func(lines []string) ([]string, error) {
+		return append(lines, "c"), nil
+	})
+	require.NoError(t, err)
+
+	info, err := os.Lstat(rc)
+	require.NoError(t, err)
+	assert.True(t, info.Mode()&os.ModeSymlink != 0, "symbolic link should be kept")
+
+	info, err = os.Stat(real)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "permissions should be preserved")
+	assertFile(t, real, "a\nb\nc\n")
+
+	// The backup is kept next to the real file, and no temporary files are
+	// left behind.
+	backups := assertBackups(t, real, 1)
+	assertFiles(t, dotfiles, "bashrc", filepath.Base(backups[0]))
+	assertFile(t, backups[0], "a\nb\n")
+
+	// A rewrite adds a backup, and keeps the first one.
+	err = (&editor{}).rewriteFile(rc, func(lines []string) ([]string, error) {
+		return append(lines, "d"), nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a\nb\n", "a\nb\nc\n"}, readFiles(t, assertBackups(t, real, 2)))
+
+	// A rewrite that does not change the content does not write the file,
+	// or back it up.
+	err = (&editor{}).rewriteFile(rc, func(lines []string) ([]string, error) {
+		return lines, nil
+	})
+	require.NoError(t, err)
+	assertBackups(t, real, 2)
+}
+
+func TestBackupFile_Exclusive(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rc := filepath.Join(dir, ".bashrc")
+	require.NoError(t, ioutil.WriteFile(rc, []byte("a\n"), 0644))
+
+	// Backups in a quick succession do not overwrite each other.
+	var backups []string
+	for i := 0; i < 3; i++ {
+		backup, err := backupFile(rc)
+		require.NoError(t, err)
+		backups = append(backups, backup)
+	}
+	assert.ElementsMatch(t, backups, assertBackups(t, rc, 3))
+	for _, backup := range backups {
+		assertFile(t, backup, "a\n")
+	}
+}
+
+// backupName matches the names of the backups of a file named "file".
+var backupName = regexp.MustCompile(`^file\.\d{8}-\d{6}\.\d{3}(-\d+)?\.bck$`)
+
+// assertBackups asserts the number of the backups of the file in path, and
+// returns their paths.
+func assertBackups(t *testing.T, path string, n int) []string {
+	t.Helper()
+	backups, err := filepath.Glob(path + ".*.bck")
+	require.NoError(t, err)
+	require.Len(t, backups, n)
+	for _, backup := range backups {
+		name := "file" + strings.TrimPrefix(filepath.Base(backup), filepath.Base(path))
+		assert.Regexp(t, backupName, name)
+	}
+	return backups
+}
+
+// readFiles returns the contents of the files in paths.
+func readFiles(t *testing.T, paths []string) []string {
+	t.Helper()
+	var contents []string
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		contents = append(contents, string(data))
+	}
+	return contents
+}
+
+// assertFiles asserts the names of the files in dir.
+func assertFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Name())
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestRewriteFile_EditError(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rc := filepath.Join(dir, ".bashrc")
+	require.NoError(t, ioutil.WriteFile(rc, []byte("a\n"), 0644))
+
+	err = (&editor{}).rewriteFile(rc, func(lines []string) ([]string, error) {
+		return nil, assert.AnError
+	})
+	assert.Equal(t, assert.AnError, err)
+	assertFile(t, rc, "a\n")
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestCreateFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-install-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "completions", "cmd.fish")
+	require.NoError(t, (&editor{}).createFile(name, "content"))
+	assertFile(t, name, "content\n")
+
+	info, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
@@ -0,0 +1,87 @@
+package install
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+// (un)install in xonsh
+// basically creates a completion script that adds a completer that runs the
+// completion command, and adds/remove from the xonsh rc file a block with
+// a line that loads it:
+//
+// source <config dir>/completions/<command>.xsh
+type xonsh struct {
+	ed        *editor
+	configDir string
+	rc        string
+}
+
+func (xonsh) Name() string {
+	return "xonsh"
+}
+
+func (x xonsh) IsInstalled(cmd, bin string) bool {
+	return x.block(cmd).isInstalled()
+}
+
+func (x xonsh) Install(cmd, bin string) error {
+	content, err := x.script(cmd, bin)
+	if err != nil {
+		return err
+	}
+	return x.ed.installScript(x.block(cmd), x.hook(cmd), x.scriptPath(cmd), content)
+}
+
+func (x xonsh) Uninstall(cmd, bin string) error {
+	return x.ed.uninstallScript(x.block(cmd), x.scriptPath(cmd))
+}
+
+func (x xonsh) block(cmd string) rcBlock {
+	return rcBlock{ed: x.ed, rc: x.rc, cmd: cmd}
+}
+
+func (x xonsh) scriptPath(cmd string) string {
+	return filepath.Join(x.configDir, "completions", cmd+".xsh")
+}
+
+func (x xonsh) hook(cmd string) string {
+	return "source " + strconv.Quote(x.scriptPath(cmd))
+}
+
+var nonIdentifier = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func (xonsh) script(cmd, bin string) (string, error) {
+	var buf bytes.Buffer
+	params := struct{ Name, Cmd, Bin string }{
+		Name: "complete_" + nonIdentifier.ReplaceAllString(cmd, "_"),
+		Cmd:  strconv.Quote(cmd),
+		Bin:  strconv.Quote(bin),
+	}
+	tmpl := template.Must(template.New("xonsh").Parse(`
+def _{{.Name}}(prefix, line, begidx, endidx, ctx):
+    import os, subprocess
+    line = line[:endidx]
+    words = line.split()
+    if not words or words[0] != {{.Cmd}}:
+        return None
+    env = dict(os.environ, COMP_LINE=line, COMP_POINT=str(len(line.encode())), COMP_SHELL="xonsh")
+    out = subprocess.run([{{.Bin}}], env=env, stdout=subprocess.PIPE, universal_newlines=True).stdout
+    return set(out.splitlines())
+
+completer add {{.Name}} _{{.Name}} start
+`))
+	err := tmpl.Execute(&buf, params)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (x xonsh) completion(cmd, bin string) (string, string, error) {
+	content, err := x.script(cmd, bin)
+	return x.scriptPath(cmd), content, err
+}
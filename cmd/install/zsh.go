@@ -1,44 +1,127 @@
 package install
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+)
 
 // (un)install in zsh
-// basically adds/remove from .zshrc:
+// basically adds/remove from .zshrc a block with a completion function,
+// that is registered with compdef:
 //
-// autoload -U +X bashcompinit && bashcompinit"
-// complete -C </path/to/completion/command> <command>
+// _complete_<command>() { ... }
+// compdef _complete_<command> <command>
+//
+// Like the completion function of a zsh completion directory, it sets
+// COMP_SHELL to "zsh", so the completion command outputs hints.
 type zsh struct {
+	ed *editor
 	rc string
 }
 
+func (zsh) Name() string {
+	return "zsh"
+}
+
 func (z zsh) IsInstalled(cmd, bin string) bool {
-	completeCmd := z.cmd(cmd, bin)
-	return lineInFile(z.rc, completeCmd)
+	return z.block(cmd).isInstalled()
 }
 
 func (z zsh) Install(cmd, bin string) error {
-	if z.IsInstalled(cmd, bin) {
+	block, completeCmd := z.block(cmd), z.cmd(cmd, bin)
+	if block.has(completeCmd) {
 		return fmt.Errorf("already installed in %s", z.rc)
 	}
+	return block.install(completeCmd)
+}
 
-	completeCmd := z.cmd(cmd, bin)
-	bashCompInit := "autoload -U +X bashcompinit && bashcompinit"
-	if !lineInFile(z.rc, bashCompInit) {
-		completeCmd = bashCompInit + "\n" + completeCmd
-	}
+func (z zsh) Uninstall(cmd, bin string) error {
+	return z.block(cmd).uninstall()
+}
 
-	return appendToFile(z.rc, completeCmd)
+func (z zsh) block(cmd string) rcBlock {
+	// Older versions wrote the complete command without a block.
+	return rcBlock{ed: z.ed, rc: z.rc, cmd: cmd, legacy: legacyComplete(cmd)}
 }
 
-func (z zsh) Uninstall(cmd, bin string) error {
-	if !z.IsInstalled(cmd, bin) {
-		return fmt.Errorf("does not installed in %s", z.rc)
+func (z zsh) cmd(cmd, bin string) string {
+	name := "_complete_" + cmd
+	return "(( $+functions[compdef] )) || { autoload -U +X compinit && compinit; }\n" +
+		name + "() {\n" + zshFunction(bin) + "\n}\n" + z.completeCmd(cmd, name, bin)
+}
+
+// completeCmd returns the command that registers the completion function.
+// If bin is not a path, it is looked up in $PATH, and the completion is
+// registered only if it is found.
+func (zsh) completeCmd(cmd, name, bin string) string {
+	if !filepath.IsAbs(bin) {
+		return fmt.Sprintf(`if command -v %s >/dev/null 2>&1; then compdef %s %s; fi`, bin, name, cmd)
 	}
+	return fmt.Sprintf("compdef %s %s", name, cmd)
+}
+
+// (un)install in a zsh completion directory, one of the directories in the
+// zsh fpath
+// basically creates a completion function file, that compinit loads:
+//
+// <completions dir>/_<command>
+//
+// The function sets COMP_SHELL to "zsh" so the completion command outputs
+// matches that replace the whole current word, as zsh does not split words
+// on "=", and hints, that the function shows as a message.
+type zshCompletion struct {
+	ed  *editor
+	dir string
+}
+
+func (zshCompletion) Name() string {
+	return "zsh"
+}
+
+func (z zshCompletion) IsInstalled(cmd, bin string) bool {
+	return z.ed.exists(z.file(cmd))
+}
+
+func (z zshCompletion) Install(cmd, bin string) error {
+	return z.ed.installFile(z.file(cmd), z.cmd(cmd, bin))
+}
+
+func (z zshCompletion) Uninstall(cmd, bin string) error {
+	return z.ed.uninstallFile(z.file(cmd))
+}
+
+func (z zshCompletion) file(cmd string) string {
+	return filepath.Join(z.dir, "_"+cmd)
+}
+
+func (zshCompletion) cmd(cmd, bin string) string {
+	return fmt.Sprintf("#compdef %s\n", cmd) + zshFunction(bin)
+}
+
+// zshFunction returns the body of the zsh completion function. It runs the
+// completion command with COMP_SHELL set to "zsh", adds the matches, and
+// shows the hints, the output lines that start with a tab, as a message.
+// COMP_POINT is the length of the line in bytes, which zsh counts without
+// the multibyte option.
+func zshFunction(bin string) string {
+	return fmt.Sprintf(`local line="${words[1,CURRENT]}" point m
+local -a matches hints
+() { setopt localoptions nomultibyte; point=${#line} }
+for m in "${(@f)$(COMP_LINE="$line" COMP_POINT=$point COMP_SHELL=zsh %s)}"; do
+  if [[ $m == $'\t'* ]]; then
+    hints+=("${m#$'\t'}")
+  elif [[ -n $m ]]; then
+    matches+=("$m")
+  fi
+done
+(( ${#hints} )) && _message -r "${(j:, :)hints}"
+compadd -S '' -- "${matches[@]}"`, bin)
+}
 
-	completeCmd := z.cmd(cmd, bin)
-	return removeFromFile(z.rc, completeCmd)
+func (z zsh) completion(cmd, bin string) (string, string, error) {
+	return z.rc, z.cmd(cmd, bin), nil
 }
 
-func (zsh) cmd(cmd, bin string) string {
-	return fmt.Sprintf("complete -o nospace -C %s %s", bin, cmd)
+func (z zshCompletion) completion(cmd, bin string) (string, string, error) {
+	return z.file(cmd), z.cmd(cmd, bin), nil
 }
@@ -24,7 +24,7 @@ type Command struct {
 
 // Predict returns all possible predictions for args according to the command struct
 func (c *Command) Predict(a Args) []string {
-	options, _ := c.predict(a)
+	options, _ := c.predict(a, nil)
 	return options
 }
 
@@ -61,16 +61,18 @@ func (f Flags) Predict(a Args) (prediction []string) {
 // only is set to true if no more options are allowed to be returned
 // those are in cases of special flag that has specific completion arguments,
 // and other flags or sub commands can't come after it.
-func (c *Command) predict(a Args) (options []string, only bool) {
+// The decisions are recorded in t, which may be nil.
+func (c *Command) predict(a Args, t *trace) (options []string, only bool) {
 
 	// search sub commands for predictions first
 	subCommandFound := false
 	for i, arg := range a.Completed {
 		if cmd, ok := c.Sub[arg]; ok {
 			subCommandFound = true
+			t.sub(arg)
 
 			// recursive call for sub command
-			options, only = cmd.predict(a.from(i))
+			options, only = cmd.predict(a.from(i), t)
 			if only {
 				return
 			}
@@ -83,11 +85,11 @@ func (c *Command) predict(a Args) (options []string, only bool) {
 
 	// if last completed word is a global flag that we need to complete
 	if predictor, ok := c.GlobalFlags[a.LastCompleted]; ok && predictor != nil {
-		Log("Predicting according to global flag %s", a.LastCompleted)
-		return predictor.Predict(a), true
+		a.Logger().Logf(LevelDebug, "Predicting according to global flag %s", a.LastCompleted)
+		return t.predict("global flag "+a.LastCompleted, "last completed word is a global flag with a predictor", predictor, a), true
 	}
 
-	options = append(options, c.GlobalFlags.Predict(a)...)
+	options = append(options, t.predict("global flags", "global flag names", c.GlobalFlags, a)...)
 
 	// if a sub command was entered, we won't add the parent command
 	// completions and we return here.
@@ -97,14 +99,14 @@ func (c *Command) predict(a Args) (options []string, only bool) {
 
 	// if last completed word is a command flag that we need to complete
 	if predictor, ok := c.Flags[a.LastCompleted]; ok && predictor != nil {
-		Log("Predicting according to flag %s", a.LastCompleted)
-		return predictor.Predict(a), true
+		a.Logger().Logf(LevelDebug, "Predicting according to flag %s", a.LastCompleted)
+		return t.predict("flag "+a.LastCompleted, "last completed word is a flag with a predictor", predictor, a), true
 	}
 
-	options = append(options, c.Sub.Predict(a)...)
-	options = append(options, c.Flags.Predict(a)...)
+	options = append(options, t.predict("sub commands", "sub command names", c.Sub, a)...)
+	options = append(options, t.predict("flags", "flag names", c.Flags, a)...)
 	if c.Args != nil {
-		options = append(options, c.Args.Predict(a)...)
+		options = append(options, t.predict("args", "positional arguments", c.Args, a)...)
 	}
 
 	return
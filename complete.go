@@ -1,6 +1,7 @@
 package complete
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -15,6 +16,35 @@ const (
 	envLine  = "COMP_LINE"
 	envPoint = "COMP_POINT"
 	envDebug = "COMP_DEBUG"
+	envShell = "COMP_SHELL"
+	// envTrace is the environment variable with the path of a file that a
+	// trace of every completion is appended to.
+	envTrace = "COMP_TRACE"
+
+	// envTcshLine is the environment variable in which tcsh passes the
+	// completed line.
+	envTcshLine = "COMMAND_LINE"
+)
+
+// Shells that have a specific output format, according to the COMP_SHELL
+// environment variable. Other shells get the matches, one per line.
+const (
+	// shellPowerShell outputs each match as the text that should replace the
+	// current word, and the match itself, separated by a tab.
+	shellPowerShell = "pwsh"
+	// Shells that replace the whole current word with the completion. They
+	// get the text that should replace the current word for each match.
+	shellNushell = "nu"
+	shellElvish  = "elvish"
+	shellXonsh   = "xonsh"
+	shellTcsh    = "tcsh"
+	// shellZsh also outputs the hints, each in a line that starts with a
+	// tab, for the completion function to show as a message.
+	shellZsh = "zsh"
+	// shellFish outputs the matches, and the hints, like zsh, each in a line
+	// that starts with a tab. The completion function prints them above the
+	// prompt, as fish can not show messages with the completion options.
+	shellFish = "fish"
 )
 
 // Complete structs define completion for a command with CLI options
@@ -22,6 +52,9 @@ type Complete struct {
 	Command Command
 	cmd.CLI
 	Out io.Writer
+	// Logger logs the messages of the completion, it is passed to the
+	// predictors in the Args. If nil, the messages are logged with Log.
+	Logger Logger
 }
 
 // New creates a new complete command.
@@ -52,22 +85,81 @@ func (c *Complete) Run() bool {
 // For installation: it assumes that flags were added and parsed before
 // it was called.
 func (c *Complete) Complete() bool {
-	line, point, ok := getEnv()
+	line, point, ok := c.getEnv()
 	if !ok {
 		// make sure flags parsed,
 		// in case they were not added in the main program
 		return c.CLI.Run()
 	}
 
-	if point >= 0 && point < len(line) {
-		line = line[:point]
+	if point < 0 || point > len(line) {
+		point = len(line)
+	}
+
+	t := newTrace()
+	defer t.write(c.logger())
+	result, err := c.completeLine("", line, point, t)
+	if err != nil {
+		c.Logf(LevelError, "Failed completing: %v", err)
+		return true
+	}
+	c.output(os.Getenv(envShell), result)
+	return true
+}
+
+// ErrPointOutOfRange is returned by CompleteLine when the cursor position
+// does not point inside the given line.
+var ErrPointOutOfRange = errors.New("complete: point out of range")
+
+// Result is the outcome of completing a command line.
+type Result struct {
+	// Args are the parsed arguments of the line, up to the cursor position.
+	Args Args
+	// Options are all the options that the command predicted.
+	Options []string
+	// Matches are the options that match the last argument, these are
+	// the options that should be presented to the user.
+	Matches []string
+	// Hints are messages of the predictors, such as a description of the
+	// expected value, see Args.Hint.
+	Hints []string
+}
+
+// CompleteLine completes the given command line, as if the cursor was at
+// point, which is a byte offset in line between 0 and len(line).
+// Unlike Complete, it does not read the environment, write to Out or exit the
+// program, so it can be used for in-process completion, such as in an
+// interactive prompt.
+func (c *Complete) CompleteLine(line string, point int) (Result, error) {
+	return c.completeLine("", line, point, nil)
+}
+
+// CompleteLineIn is like CompleteLine, but relative paths are completed in
+// the working directory dir instead of the current working directory of the
+// process, such as when completing a line for another process.
+func (c *Complete) CompleteLineIn(dir, line string, point int) (Result, error) {
+	return c.completeLine(dir, line, point, nil)
+}
+
+// completeLine completes the line in the working directory dir, and records
+// the decisions in t, which may be nil.
+func (c *Complete) completeLine(dir, line string, point int, t *trace) (Result, error) {
+	if point < 0 || point > len(line) {
+		t.fail(line, point, ErrPointOutOfRange)
+		return Result{}, ErrPointOutOfRange
 	}
+	line = line[:point]
 
-	Log("Completing phrase: %s", line)
+	c.Logf(LevelDebug, "Completing phrase: %s", line)
 	a := newArgs(line)
-	Log("Completing last field: %s", a.Last)
-	options := c.Command.Predict(a)
-	Log("Options: %s", options)
+	a.Dir = dir
+	a.logger = c.logger()
+	var hints []string
+	a.hints = &hints
+	t.args(line, point, a)
+	c.Logf(LevelDebug, "Completing last field: %s", a.Last)
+	options, _ := c.Command.predict(a, t)
+	c.Logf(LevelDebug, "Options: %s", options)
 
 	// filter only options that match the last argument
 	matches := []string{}
@@ -76,13 +168,30 @@ func (c *Complete) Complete() bool {
 			matches = append(matches, option)
 		}
 	}
-	Log("Matches: %s", matches)
-	c.output(matches)
-	return true
+	c.Logf(LevelDebug, "Matches: %s", matches)
+	t.result(options, matches)
+	return Result{Args: a, Options: options, Matches: matches, Hints: hints}, nil
+}
+
+// Logf logs a message with the logger of the completion.
+func (c *Complete) Logf(level Level, format string, args ...interface{}) {
+	c.logger().Logf(level, format, args...)
 }
 
-func getEnv() (line string, point int, ok bool) {
+func (c *Complete) logger() Logger {
+	if c.Logger == nil {
+		return defaultLogger
+	}
+	return c.Logger
+}
+
+func (c *Complete) getEnv() (line string, point int, ok bool) {
 	line = os.Getenv(envLine)
+	if line == "" && os.Getenv(envShell) == shellTcsh {
+		// tcsh does not pass the cursor position, complete the whole line.
+		line = os.Getenv(envTcshLine)
+		return line, len(line), line != ""
+	}
 	if line == "" {
 		return
 	}
@@ -90,15 +199,54 @@ func getEnv() (line string, point int, ok bool) {
 	if err != nil {
 		// If failed parsing point for some reason, set it to point
 		// on the end of the line.
-		Log("Failed parsing point %s: %v", os.Getenv(envPoint), err)
+		c.Logf(LevelError, "Failed parsing point %s: %v", os.Getenv(envPoint), err)
 		point = len(line)
 	}
 	return line, point, true
 }
 
-func (c *Complete) output(options []string) {
+func (c *Complete) output(shell string, result Result) {
+	c.Output(c.Out, shell, result)
+}
+
+// Output writes the result of a completion to w, in the output format of
+// the shell, as it is named in the COMP_SHELL environment variable by the
+// completion scripts. It can be used to answer completion requests that are
+// not read from the environment, such as those of the completion daemon.
+func (c *Complete) Output(w io.Writer, shell string, result Result) {
 	// stdout of program defines the complete options
-	for _, option := range options {
-		fmt.Fprintln(c.Out, option)
+	// Some shells replace the whole current word with the completion text,
+	// which should include the part of the word that was not completed, such
+	// as "-flag=" in "-flag=value".
+	prefix := strings.TrimSuffix(result.Args.word, result.Args.Last)
+	switch shell {
+	case shellPowerShell:
+		for _, option := range result.Matches {
+			fmt.Fprintf(w, "%s%s\t%s\n", prefix, option, option)
+		}
+	case shellNushell, shellElvish, shellXonsh, shellTcsh, shellZsh:
+		for _, option := range result.Matches {
+			fmt.Fprintln(w, prefix+option)
+		}
+		if shell == shellZsh {
+			outputHints(w, result.Hints)
+		}
+	case shellFish:
+		for _, option := range result.Matches {
+			fmt.Fprintln(w, option)
+		}
+		outputHints(w, result.Hints)
+	default:
+		for _, option := range result.Matches {
+			fmt.Fprintln(w, option)
+		}
+	}
+}
+
+// outputHints outputs each hint in a line that starts with a tab, which
+// the completion functions of the shells tell apart from the matches.
+func outputHints(w io.Writer, hints []string) {
+	for _, hint := range hints {
+		fmt.Fprintf(w, "\t%s\n", hint)
 	}
 }
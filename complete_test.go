@@ -2,8 +2,11 @@ package complete
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -412,3 +415,290 @@ func equalSlices(a, b []string) bool {
 	}
 	return true
 }
+
+func TestCompleter_CompleteLine(t *testing.T) {
+	initTests()
+
+	c := Command{
+		Sub: Commands{
+			"sub1": {},
+			"sub2": {},
+		},
+		Flags: Flags{
+			"-flag": PredictSet("opt1", "opt2"),
+		},
+	}
+	cmp := New("cmd", c)
+
+	tests := []struct {
+		line    string
+		point   int
+		want    []string
+		wantErr error
+	}{
+		{line: "cmd ", point: 4, want: []string{"sub1", "sub2"}},
+		{line: "cmd sub1", point: 8, want: []string{"sub1"}},
+		{line: "cmd -flag o", point: 11, want: []string{"opt1", "opt2"}},
+		{line: "cmd -flag opt1", point: 4, want: []string{"sub1", "sub2"}},
+		{line: "cmd ", point: 5, wantErr: ErrPointOutOfRange},
+		{line: "cmd ", point: -1, wantErr: ErrPointOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s@%d", tt.line, tt.point), func(t *testing.T) {
+			result, err := cmp.CompleteLine(tt.line, tt.point)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got := result.Matches
+			sort.Strings(got)
+			sort.Strings(tt.want)
+
+			if !equalSlices(got, tt.want) {
+				t.Errorf("failed '%s'\ngot = %s\nwant: %s", t.Name(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleter_OutputPowerShell(t *testing.T) {
+	initTests()
+
+	c := Command{
+		Flags: Flags{
+			"-flag": PredictSet("opt1", "opt2"),
+		},
+	}
+	cmp := New("cmd", c)
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "cmd -flag o", want: []string{"opt1\topt1", "opt2\topt2"}},
+		{line: "cmd -flag=o", want: []string{"-flag=opt1\topt1", "-flag=opt2\topt2"}},
+		{line: "cmd -flag=", want: []string{"-flag=opt1\topt1", "-flag=opt2\topt2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			result, err := cmp.CompleteLine(tt.line, len(tt.line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := bytes.NewBuffer(nil)
+			cmp.Out = b
+			cmp.output(shellPowerShell, result)
+
+			got := parseOutput(b.String())
+			sort.Strings(got)
+
+			if !equalSlices(got, tt.want) {
+				t.Errorf("failed '%s'\ngot = %q\nwant: %q", t.Name(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleter_CompleteTcsh(t *testing.T) {
+	initTests()
+
+	c := Command{
+		Flags: Flags{
+			"-flag": PredictSet("opt1", "opt2"),
+		},
+	}
+	cmp := New("cmd", c)
+
+	os.Unsetenv(envLine)
+	os.Unsetenv(envPoint)
+	os.Setenv(envShell, shellTcsh)
+	os.Setenv(envTcshLine, "cmd -flag=o")
+	defer os.Unsetenv(envShell)
+	defer os.Unsetenv(envTcshLine)
+
+	b := bytes.NewBuffer(nil)
+	cmp.Out = b
+	if !cmp.Complete() {
+		t.Fatal("expected completion to run")
+	}
+
+	got := parseOutput(b.String())
+	sort.Strings(got)
+	want := []string{"-flag=opt1", "-flag=opt2"}
+
+	if !equalSlices(got, want) {
+		t.Errorf("got = %q\nwant: %q", got, want)
+	}
+}
+
+func TestCompleter_Trace(t *testing.T) {
+	initTests()
+
+	c := Command{
+		Sub: Commands{
+			"sub": {
+				Flags: Flags{
+					"-flag": PredictSet("opt1", "opt2", "other"),
+				},
+			},
+		},
+	}
+	cmp := New("cmd", c)
+	cmp.Out = bytes.NewBuffer(nil)
+
+	f, err := ioutil.TempFile("", "complete-trace-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	os.Setenv(envLine, "cmd sub -flag o")
+	os.Setenv(envPoint, "15")
+	os.Setenv(envTrace, f.Name())
+	defer os.Unsetenv(envLine)
+	defer os.Unsetenv(envPoint)
+	defer os.Unsetenv(envTrace)
+
+	if !cmp.Complete() {
+		t.Fatal("expected completion to run")
+	}
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got trace
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("failed parsing trace %q: %v", data, err)
+	}
+
+	if got.Line != "cmd sub -flag o" || got.Point != 15 {
+		t.Errorf("got line %q, point %d", got.Line, got.Point)
+	}
+	if got.Args.Last != "o" || got.Args.LastCompleted != "-flag" {
+		t.Errorf("got args %+v", got.Args)
+	}
+	if !equalSlices(got.Path, []string{"sub"}) {
+		t.Errorf("got path %q", got.Path)
+	}
+	if n := len(got.Predictions); n == 0 || got.Predictions[n-1].Predictor != "flag -flag" || got.Predictions[n-1].Count != 3 {
+		t.Errorf("got predictions %+v", got.Predictions)
+	}
+	if got.Options != 3 || !equalSlices(got.Matches, []string{"opt1", "opt2", "other"}) {
+		t.Errorf("got options %d, matches %q", got.Options, got.Matches)
+	}
+}
+
+func TestCompleter_TraceError(t *testing.T) {
+	f, err := ioutil.TempFile("", "complete-trace-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	os.Setenv(envTrace, f.Name())
+	defer os.Unsetenv(envTrace)
+
+	cmp := New("cmd", Command{})
+	tr := newTrace()
+	if _, err := cmp.completeLine("", "cmd", 10, tr); err != ErrPointOutOfRange {
+		t.Fatalf("got error %v, want %v", err, ErrPointOutOfRange)
+	}
+	tr.write(cmp.logger())
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got trace
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("failed parsing trace %q: %v", data, err)
+	}
+	if got.Line != "cmd" || got.Point != 10 || got.Error != ErrPointOutOfRange.Error() {
+		t.Errorf("got line %q, point %d, error %q", got.Line, got.Point, got.Error)
+	}
+}
+
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Logf(level Level, format string, args ...interface{}) {
+	l.messages = append(l.messages, level.String()+": "+fmt.Sprintf(format, args...))
+}
+
+func TestCompleter_Logger(t *testing.T) {
+	t.Parallel()
+
+	var l testLogger
+	c := Command{
+		Args: PredictFunc(func(a Args) []string {
+			a.Logger().Logf(LevelError, "from predictor")
+			return nil
+		}),
+	}
+	cmp := New("cmd", c)
+	cmp.Logger = &l
+
+	if _, err := cmp.CompleteLine("cmd ", 4); err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, m := range l.messages {
+		if m == "error: from predictor" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("predictor message was not logged, got: %q", l.messages)
+	}
+	if len(l.messages) < 2 || l.messages[0] != "debug: Completing phrase: cmd " {
+		t.Errorf("got messages: %q", l.messages)
+	}
+}
+
+func TestCompleter_OutputHints(t *testing.T) {
+	t.Parallel()
+
+	c := Command{
+		Flags: Flags{
+			"-port": PredictRegexp(regexp.MustCompile(`^[0-9]+$`), "<port>"),
+			"-mode": PredictOr(PredictSet("fast"), PredictValid(func(string) bool { return true }, "<mode>")),
+		},
+	}
+
+	tests := []struct {
+		shell string
+		line  string
+		want  string
+	}{
+		{shell: shellZsh, line: "cmd -port ", want: "\t<port>\n"},
+		{shell: shellZsh, line: "cmd -port x", want: ""},
+		{shell: shellZsh, line: "cmd -mode f", want: "fast\n\t<mode>\n"},
+		{shell: shellFish, line: "cmd -port 8", want: "\t<port>\n"},
+		{shell: shellFish, line: "cmd -port ", want: "\t<port>\n"},
+		{shell: shellFish, line: "cmd -mode ", want: "fast\n\t<mode>\n"},
+		{shell: "", line: "cmd -port 8", want: ""},
+	}
+
+	for _, tt := range tests {
+		cmp := New("cmd", c)
+		b := bytes.NewBuffer(nil)
+		cmp.Out = b
+		result, err := cmp.CompleteLine(tt.line, len(tt.line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmp.output(tt.shell, result)
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.shell, tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package completetest helps testing the completion of commands.
+//
+// A test case is a command line, with a cursor marker where the completion
+// is requested, and the expected completion candidates:
+//
+//	func TestCompletion(t *testing.T) {
+//		completetest.Check(t, cmd, "cmd sub -fl|", "-flag")
+//		completetest.Check(t, cmd, "cmd sub -flag |", "opt1", "opt2")
+//	}
+//
+// File predictors complete files relative to the working directory, Dir
+// creates an isolated directory with given files for such tests, and CheckIn
+// completes lines in it:
+//
+//	dir, remove := completetest.Dir(t, "a.txt", "sub/")
+//	defer remove()
+//	completetest.CheckIn(t, cmd, dir, "cmd -file |", "./", "a.txt", "sub/")
+package completetest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/posener/complete"
+	"github.com/posener/complete/internal/diff"
+)
+
+// Cursor marks the cursor position in a tested line. A line without the
+// cursor marker is completed at its end.
+const Cursor = "|"
+
+// Case is a completion test case.
+type Case struct {
+	// Line is the command line, starting with the command name, with an
+	// optional Cursor marker.
+	Line string
+	// Want are the expected completion candidates, in any order.
+	Want []string
+	// Dir is the working directory that the line is completed in, such as
+	// a directory created by Dir. If empty, it is the current directory.
+	Dir string
+}
+
+// Complete returns the sorted completion candidates of the line, which may
+// contain a Cursor marker.
+func Complete(c complete.Command, line string) ([]string, error) {
+	return CompleteIn(c, "", line)
+}
+
+// CompleteIn is like Complete, but relative paths are completed in the
+// working directory dir, see complete.Complete.CompleteLineIn.
+func CompleteIn(c complete.Command, dir, line string) ([]string, error) {
+	point := strings.Index(line, Cursor)
+	if point < 0 {
+		point = len(line)
+	} else {
+		line = line[:point] + line[point+len(Cursor):]
+	}
+	name := line
+	if fields := strings.Fields(line); len(fields) > 0 {
+		name = fields[0]
+	}
+	result, err := complete.New(name, c).CompleteLineIn(dir, line, point)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(result.Matches)
+	return result.Matches, nil
+}
+
+// Check tests that the completion candidates of the line are want, in any
+// order. On failure, it reports a diff of the expected and the actual
+// candidates.
+func Check(t testing.TB, c complete.Command, line string, want ...string) {
+	t.Helper()
+	CheckIn(t, c, "", line, want...)
+}
+
+// CheckIn is like Check, but relative paths are completed in the working
+// directory dir.
+func CheckIn(t testing.TB, c complete.Command, dir, line string, want ...string) {
+	t.Helper()
+	got, err := CompleteIn(c, dir, line)
+	if err != nil {
+		t.Errorf("completing %q: %v", line, err)
+		return
+	}
+	if d := Diff(want, got); d != "" {
+		t.Errorf("completing %q:\n%s", line, d)
+	}
+}
+
+// Run runs every case as a sub test of t, named by its line.
+func Run(t *testing.T, c complete.Command, cases []Case) {
+	t.Helper()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.Line, func(t *testing.T) {
+			CheckIn(t, c, tt.Dir, tt.Line, tt.Want...)
+		})
+	}
+}
+
+// Diff returns a unified diff of the expected and the actual candidates,
+// one per line, in sorted order. It returns an empty string if they are
+// equal.
+func Diff(want, got []string) string {
+	return diff.Unified("want", "got", lines(want), lines(got))
+}
+
+func lines(candidates []string) string {
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+	var b strings.Builder
+	for _, c := range sorted {
+		fmt.Fprintln(&b, c)
+	}
+	return b.String()
+}
+
+// Dir creates a temporary directory with the given files, and returns its
+// path. Files that end with a "/" are created as directories, and the parent
+// directories of all files are created. Lines are completed in it with
+// CheckIn, CompleteIn or Case.Dir, without changing the working directory of
+// the process, so tests that use Dir can run in parallel.
+// It also returns a function that removes the directory, which should be
+// deferred.
+func Dir(t testing.TB, files ...string) (dir string, remove func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "completetest-")
+	if err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+	remove = func() { os.RemoveAll(dir) }
+
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if strings.HasSuffix(f, "/") {
+			err = os.MkdirAll(path, 0755)
+		} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = ioutil.WriteFile(path, nil, 0644)
+		}
+		if err != nil {
+			remove()
+			t.Fatalf("creating %s: %v", f, err)
+		}
+	}
+	return dir, remove
+}
@@ -0,0 +1,79 @@
+package completetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cmd = complete.Command{
+	Sub: complete.Commands{
+		"sub": {
+			Flags: complete.Flags{
+				"-flag": complete.PredictSet("opt1", "opt2"),
+				"-file": complete.PredictFiles("*.txt"),
+			},
+		},
+	},
+	Flags: complete.Flags{
+		"-h": complete.PredictNothing,
+	},
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir, remove := Dir(t, "a.txt")
+	defer remove()
+
+	Run(t, cmd, []Case{
+		{Line: "cmd ", Want: []string{"sub"}},
+		{Line: "cmd -", Want: []string{"-h"}},
+		{Line: "cmd sub -fl", Want: []string{"-flag"}},
+		{Line: "cmd sub -flag ", Want: []string{"opt2", "opt1"}},
+		{Line: "cmd sub -flag o| sub", Want: []string{"opt1", "opt2"}},
+		{Line: "cmd s|ub -flag", Want: []string{"sub"}},
+		{Line: "cmd sub -file ", Want: []string{"./", "a.txt"}, Dir: dir},
+	})
+}
+
+func TestDir(t *testing.T) {
+	t.Parallel()
+
+	dir, remove := Dir(t, "a.txt", "b.go", "dir/c.txt", "empty/")
+	defer remove()
+
+	CheckIn(t, cmd, dir, "cmd sub -file ", "./", "a.txt", "dir/", "empty/")
+	CheckIn(t, cmd, dir, "cmd sub -file dir/", "dir/", "dir/c.txt")
+}
+
+// fakeT records the errors of a test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCheck_Diff(t *testing.T) {
+	t.Parallel()
+
+	ft := &fakeT{TB: t}
+	Check(ft, cmd, "cmd sub -flag ", "opt1", "opt3")
+	require.Len(t, ft.errors, 1)
+	assert.Equal(t, `completing "cmd sub -flag ":
+--- want
++++ got
+@@ -1,2 +1,2 @@
+ opt1
+-opt3
++opt2
+`, ft.errors[0])
+}
@@ -0,0 +1,124 @@
+package completetest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/posener/complete"
+	"github.com/posener/complete/internal/diff"
+)
+
+// Update makes Golden write the golden files instead of comparing with them.
+// It is set when the COMPLETETEST_UPDATE environment variable is set to a
+// true value, such as "1". Tests can also bind it to a flag of their own:
+//
+//	flag.BoolVar(&completetest.Update, "update", false, "update golden files")
+var Update, _ = strconv.ParseBool(os.Getenv("COMPLETETEST_UPDATE"))
+
+// Lines returns representative lines for a command tree, named name. For
+// every sub command path, there are lines that complete the sub command
+// names and arguments, the flag names, and the value of every flag that
+// has a predictor.
+func Lines(c complete.Command, name string) []string {
+	var lines []string
+	walk(c, name, nil, func(prefix string, c complete.Command, globals complete.Flags) {
+		lines = append(lines, prefix+" ", prefix+" -")
+		for _, f := range sortedFlags(c.Flags, globals) {
+			lines = append(lines, prefix+" "+f+" ")
+		}
+	})
+	return lines
+}
+
+// walk calls fn for the command and for every sub command, in sorted order,
+// with the line that leads to the command, and the global flags of the
+// command and of its parents.
+func walk(c complete.Command, prefix string, globals complete.Flags, fn func(string, complete.Command, complete.Flags)) {
+	merged := complete.Flags{}
+	for f, p := range globals {
+		merged[f] = p
+	}
+	for f, p := range c.GlobalFlags {
+		merged[f] = p
+	}
+	fn(prefix, c, merged)
+
+	subs := make([]string, 0, len(c.Sub))
+	for sub := range c.Sub {
+		subs = append(subs, sub)
+	}
+	sort.Strings(subs)
+	for _, sub := range subs {
+		walk(c.Sub[sub], prefix+" "+sub, merged, fn)
+	}
+}
+
+// sortedFlags returns the sorted names of the flags that have predictors.
+func sortedFlags(sets ...complete.Flags) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, flags := range sets {
+		for f, p := range flags {
+			if p != nil && !seen[f] {
+				seen[f] = true
+				names = append(names, f)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot returns the completion candidates of the lines, in the format of
+// the golden files: every line followed by its sorted candidates, indented.
+func Snapshot(c complete.Command, lines []string) (string, error) {
+	var b strings.Builder
+	for _, line := range lines {
+		candidates, err := Complete(c, line)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s%s\n", line, Cursor)
+		for _, candidate := range candidates {
+			fmt.Fprintf(&b, "\t%s\n", candidate)
+		}
+	}
+	return b.String(), nil
+}
+
+// Golden compares the completions of the representative lines of a command
+// tree, see Lines, with the golden file in path. On change, it reports a
+// diff of the golden file and the current completions.
+// Run the tests with COMPLETETEST_UPDATE=1, see Update, to write the current
+// completions to the golden file.
+func Golden(t testing.TB, c complete.Command, name, path string) {
+	t.Helper()
+	got, err := Snapshot(c, Lines(c, name))
+	if err != nil {
+		t.Fatalf("completing %s: %v", name, err)
+	}
+
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("creating golden file directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file, run with COMPLETETEST_UPDATE=1 to create it: %v", err)
+	}
+	if d := diff.Unified(path, "got", string(want), got); d != "" {
+		t.Errorf("completions changed, run with COMPLETETEST_UPDATE=1 to update the golden file:\n%s", d)
+	}
+}
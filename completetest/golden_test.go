@@ -0,0 +1,51 @@
+package completetest
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+)
+
+var tree = complete.Command{
+	Sub: complete.Commands{
+		"build": {
+			Flags: complete.Flags{
+				"-o":    complete.PredictSet("out", "bin"),
+				"-race": complete.PredictNothing,
+			},
+		},
+		"run": {
+			Args: complete.PredictSet("main.go"),
+		},
+	},
+	GlobalFlags: complete.Flags{
+		"-v":    nil,
+		"-mode": complete.PredictSet("fast", "slow"),
+	},
+}
+
+func TestLines(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{
+		"cmd ", "cmd -", "cmd -mode ",
+		"cmd build ", "cmd build -", "cmd build -mode ", "cmd build -o ",
+		"cmd run ", "cmd run -", "cmd run -mode ",
+	}, Lines(tree, "cmd"))
+}
+
+func TestGolden(t *testing.T) {
+	t.Parallel()
+
+	Golden(t, tree, "cmd", "testdata/tree.golden")
+}
+
+func TestUpdate_NoFlag(t *testing.T) {
+	t.Parallel()
+
+	// The package does not register flags in the flag sets of the programs
+	// that import it.
+	assert.Nil(t, flag.Lookup("completetest.update"))
+}
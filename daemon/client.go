@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Query sends a completion request for the given line and cursor position to
+// the daemon listening on the Unix socket in path, and returns the lines of
+// its reply, which are in the output format of shell, as in COMP_SHELL. The
+// request is completed in the current working directory. Like Listen, it
+// refuses a socket in a directory that other users can access.
+func Query(path, shell, line string, point int) ([]string, error) {
+	if err := checkDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	_, err = fmt.Fprintf(conn, "%d\n%s\n%s\n%s\n", point, dir, shell, line)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	s := bufio.NewScanner(conn)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	return lines, s.Err()
+}
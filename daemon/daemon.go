@@ -0,0 +1,179 @@
+// Package daemon serves completion from a long running process over a Unix
+// socket.
+//
+// Programs with an expensive startup pay for it on every TAB press, since the
+// shell runs the completion binary for each completion. Such programs can run
+// a completion daemon that keeps the command tree, and the caches of its
+// predictors, warm:
+//
+//	cmp := complete.New("mytool", command)
+//	path, err := daemon.SocketPath("mytool")
+//	if err != nil { ... }
+//	err = daemon.ListenAndServe(cmp, path)
+//
+// And install a client shim, that forwards the completion requests to the
+// daemon and falls back to running the binary when it is down, using
+// install.InstallShim from the cmd/install package.
+//
+// The protocol is line based: a client connects to the socket and writes
+// four lines: the cursor position (COMP_POINT), the working directory, the
+// shell (COMP_SHELL) and the command line (COMP_LINE). The daemon completes
+// the line in the given working directory, writes the completion in the
+// output format of the shell, like the completion binary does, and closes
+// the connection.
+package daemon
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/posener/complete"
+)
+
+// timeout limits the time of handling a single completion request.
+const timeout = 5 * time.Second
+
+// SocketPath returns the default per-user socket path of the completion
+// daemon of the given command name, in $XDG_RUNTIME_DIR. It fails when
+// $XDG_RUNTIME_DIR is not set, rather than using a directory that other users
+// can write to, such as /tmp.
+func SocketPath(name string) (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return "", errors.New("XDG_RUNTIME_DIR is not set, a socket path must be given")
+	}
+	return filepath.Join(dir, "complete", name+".sock"), nil
+}
+
+// ListenAndServe listens on the Unix socket in path and serves completion
+// requests using c.
+func ListenAndServe(c *complete.Complete, path string) error {
+	l, err := Listen(path)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return Serve(l, c)
+}
+
+// Listen listens on a Unix socket in path. The directory of the socket is
+// created if needed. It must be a directory, not a symbolic link, that is
+// owned by the current user and accessible only by them, otherwise another
+// user could read or answer the completion requests. A stale socket file,
+// left by a daemon that did not exit cleanly, is removed.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := checkDir(dir); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a completion daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// Serve serves completion requests that are accepted by l using c.
+// It returns when l fails accepting connections, for example, when it
+// is closed.
+//
+// Requests are handled one at a time, since predictors, and their caches, are
+// not required to be safe for concurrent use. Relative paths are completed
+// in the working directory of the client, which is passed to the predictors
+// in Args.Dir, without changing the working directory of the process. Custom
+// predictors must resolve relative paths against Args.Dir.
+func Serve(l net.Listener, c *complete.Complete) error {
+	s := server{c: c}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+type server struct {
+	c  *complete.Complete
+	mu sync.Mutex
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	req, err := readRequest(bufio.NewReader(conn))
+	if err != nil {
+		s.c.Logf(complete.LevelError, "Failed reading request: %v", err)
+		return
+	}
+
+	result, err := s.complete(req.line, req.point, req.dir)
+	if err != nil {
+		s.c.Logf(complete.LevelError, "Failed completing %q: %v", req.line, err)
+		return
+	}
+
+	w := bufio.NewWriter(conn)
+	s.c.Output(w, req.shell, result)
+	if err := w.Flush(); err != nil {
+		s.c.Logf(complete.LevelError, "Failed writing response: %v", err)
+	}
+}
+
+// complete completes the line in the given working directory.
+func (s *server) complete(line string, point int, dir string) (complete.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if point < 0 || point > len(line) {
+		point = len(line)
+	}
+	return s.c.CompleteLineIn(dir, line, point)
+}
+
+// request is a completion request of a client.
+type request struct {
+	line  string
+	point int
+	// dir is the working directory of the client.
+	dir string
+	// shell is the shell that requested the completion, as in the
+	// COMP_SHELL environment variable, which selects the output format.
+	shell string
+}
+
+func readRequest(r *bufio.Reader) (req request, err error) {
+	var fields [4]string
+	for i := range fields {
+		fields[i], err = r.ReadString('\n')
+		if err != nil {
+			return request{}, err
+		}
+		fields[i] = strings.TrimSuffix(fields[i], "\n")
+	}
+	req = request{line: fields[3], dir: fields[1], shell: fields[2]}
+	req.point, err = strconv.Atoi(fields[0])
+	if err != nil {
+		// If failed parsing point for some reason, set it to point
+		// on the end of the line.
+		req.point = len(req.line)
+	}
+	return req, nil
+}
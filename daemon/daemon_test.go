@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-daemon-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sock", "cmd.sock")
+
+	cmp := complete.New("cmd", complete.Command{
+		Sub: complete.Commands{
+			"sub1": {},
+			"sub2": {},
+		},
+		Flags: complete.Flags{
+			"-flag": complete.PredictSet("opt1", "opt2"),
+			"-name": complete.PredictFunc(func(a complete.Args) []string {
+				a.Hint("a name")
+				return nil
+			}),
+		},
+	})
+
+	l, err := Listen(path)
+	require.NoError(t, err)
+	defer l.Close()
+	go Serve(l, cmp)
+
+	_, err = Listen(path)
+	assert.Error(t, err, "second daemon should not listen on the same socket")
+
+	tests := []struct {
+		shell string
+		line  string
+		point int
+		want  []string
+	}{
+		{line: "cmd ", point: 4, want: []string{"sub1", "sub2"}},
+		{line: "cmd -flag o", point: 11, want: []string{"opt1", "opt2"}},
+		{line: "cmd -flag o", point: 4, want: []string{"sub1", "sub2"}},
+		{line: "cmd su", point: 100, want: []string{"sub1", "sub2"}},
+		{line: "cmd x", point: 5, want: nil},
+		{shell: "bash", line: "cmd -flag=o", point: 11, want: []string{"opt1", "opt2"}},
+		{shell: "zsh", line: "cmd -flag=o", point: 11, want: []string{"-flag=opt1", "-flag=opt2"}},
+		{shell: "zsh", line: "cmd -name ", point: 10, want: []string{"\ta name"}},
+		{shell: "pwsh", line: "cmd -flag=o", point: 11, want: []string{"-flag=opt1\topt1", "-flag=opt2\topt2"}},
+	}
+
+	for _, tt := range tests {
+		got, err := Query(path, tt.shell, tt.line, tt.point)
+		require.NoError(t, err)
+		sort.Strings(got)
+		assert.Equal(t, tt.want, got, "%s line %q@%d", tt.shell, tt.line, tt.point)
+	}
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-daemon-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cmd.sock")
+
+	// Leave a socket file without a listener behind it.
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	l, err = Listen(path)
+	require.NoError(t, err)
+	l.Close()
+}
+
+func TestServe_Dir(t *testing.T) {
+	t.Parallel()
+
+	work, err := ioutil.TempDir("", "complete-daemon-")
+	require.NoError(t, err)
+	defer os.RemoveAll(work)
+	require.NoError(t, os.Mkdir(filepath.Join(work, "sub"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(work, "file.txt"), nil, 0600))
+
+	cmp := complete.New("cmd", complete.Command{Args: complete.PredictFiles("*.txt")})
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	s := server{c: cmp}
+	result, err := s.complete("cmd ", 4, work)
+	require.NoError(t, err)
+	got := result.Matches
+	sort.Strings(got)
+	assert.Equal(t, []string{"./", "file.txt", "sub/"}, got)
+
+	after, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, wd, after, "working directory of the process should not change")
+}
+
+func TestListen_InsecureDir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "complete-daemon-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	shared := filepath.Join(dir, "shared")
+	require.NoError(t, os.Mkdir(shared, 0700))
+	require.NoError(t, os.Chmod(shared, 0777))
+	_, err = Listen(filepath.Join(shared, "cmd.sock"))
+	assert.Error(t, err, "world writable directory")
+	_, err = Query(filepath.Join(shared, "cmd.sock"), "", "cmd ", 4)
+	assert.Error(t, err, "world writable directory")
+
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(dir, link))
+	_, err = Listen(filepath.Join(link, "cmd.sock"))
+	assert.Error(t, err, "symbolic link directory")
+}
+
+func TestSocketPath(t *testing.T) {
+	defer os.Setenv("XDG_RUNTIME_DIR", os.Getenv("XDG_RUNTIME_DIR"))
+
+	os.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	path, err := SocketPath("cmd")
+	require.NoError(t, err)
+	assert.Equal(t, "/run/user/1000/complete/cmd.sock", path)
+
+	os.Setenv("XDG_RUNTIME_DIR", "")
+	_, err = SocketPath("cmd")
+	assert.Error(t, err)
+}
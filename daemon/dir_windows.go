@@ -0,0 +1,20 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkDir returns an error if the socket directory dir is not a directory.
+// Windows has no Unix file ownership, the directory access is controlled by
+// its ACL.
+func checkDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("socket directory %s is not a directory", dir)
+	}
+	return nil
+}
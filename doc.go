@@ -36,6 +36,11 @@ Supported shells:
 - [x] bash
 - [x] zsh
 - [x] fish
+- [x] PowerShell
+- [x] nushell
+- [x] elvish
+- [x] xonsh
+- [x] tcsh
 
 Usage
 
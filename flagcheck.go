@@ -0,0 +1,109 @@
+package complete
+
+import (
+	"flag"
+	"sort"
+	"strings"
+)
+
+// CheckFlags compares the command tree with the flags that the program
+// defines, and returns the differences as problems, in a stable order.
+// flagSets maps the sub command paths, the names of the sub commands from
+// the command separated by spaces, to their flag sets. The command itself
+// is the empty path. Commands that are not in flagSets are not checked.
+//
+// A flag "name" in a flag set is completed by the "-name" or "--name"
+// flag of its command, or by a global flag of the command or of one of its
+// parents.
+func (c *Command) CheckFlags(flagSets map[string]*flag.FlagSet) []Problem {
+	var v validator
+	v.checkFlags(c, nil, nil, flagSets)
+
+	var unknown []string
+	for path := range flagSets {
+		if c.find(path) == nil {
+			unknown = append(unknown, path)
+		}
+	}
+	sort.Strings(unknown)
+	for _, path := range unknown {
+		v.add(UnknownCommand, strings.Fields(path), "", "flags are defined for a sub command that is not completed")
+	}
+	return v.problems
+}
+
+// find returns the sub command in the given path, or nil if it does not
+// exist.
+func (c *Command) find(path string) *Command {
+	for _, name := range strings.Fields(path) {
+		sub, ok := c.Sub[name]
+		if !ok {
+			return nil
+		}
+		c = &sub
+	}
+	return c
+}
+
+func (v *validator) checkFlags(c *Command, path []string, globals Flags, flagSets map[string]*flag.FlagSet) {
+	inherited := Flags{}
+	for name, p := range globals {
+		inherited[name] = p
+	}
+	for name, p := range c.GlobalFlags {
+		inherited[name] = p
+	}
+
+	if fs, ok := flagSets[strings.Join(path, " ")]; ok {
+		fs.VisitAll(func(f *flag.Flag) {
+			name, p, ok := lookupFlag(f.Name, c.Flags, inherited)
+			if !ok {
+				v.add(MissingFlag, path, "-"+f.Name, "flag is not completed")
+				return
+			}
+			isBool := isBoolFlag(f)
+			switch {
+			case isBool && p != nil:
+				v.add(FlagPredictorMismatch, path, name, "boolean flag has a predictor")
+			case !isBool && p == nil:
+				v.add(FlagPredictorMismatch, path, name, "flag expects a value but has no predictor")
+			}
+		})
+		// Global flags are checked against the flag set of the command that
+		// declares them.
+		for _, set := range []Flags{c.Flags, c.GlobalFlags} {
+			for _, name := range sortedKeys(set) {
+				if fs.Lookup(strings.TrimLeft(name, "-")) == nil {
+					v.add(StaleFlag, path, name, "flag is not defined by the program")
+				}
+			}
+		}
+	}
+
+	subs := make([]string, 0, len(c.Sub))
+	for name := range c.Sub {
+		subs = append(subs, name)
+	}
+	sort.Strings(subs)
+	for _, name := range subs {
+		sub := c.Sub[name]
+		v.checkFlags(&sub, append(append([]string(nil), path...), name), inherited, flagSets)
+	}
+}
+
+// lookupFlag returns the completion flag of a flag set flag name.
+func lookupFlag(name string, sets ...Flags) (string, Predictor, bool) {
+	for _, set := range sets {
+		for _, prefix := range []string{"-", "--"} {
+			if p, ok := set[prefix+name]; ok {
+				return prefix + name, p, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,68 @@
+package complete
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestCommand_CheckFlags(t *testing.T) {
+	t.Parallel()
+
+	root := flag.NewFlagSet("cmd", flag.ContinueOnError)
+	root.Bool("v", false, "")
+	root.String("mode", "", "")
+	root.Int("missing", 0, "")
+
+	build := flag.NewFlagSet("build", flag.ContinueOnError)
+	build.String("o", "", "")
+	build.Bool("race", false, "")
+	build.Bool("x", false, "")
+	build.String("tags", "", "")
+
+	c := Command{
+		Sub: Commands{
+			"build": {
+				Flags: Flags{
+					"-o":     PredictFiles("*"),
+					"-race":  PredictSet("true", "false"),
+					"--x":    PredictNothing,
+					"-tags":  PredictNothing,
+					"-stale": PredictAnything,
+				},
+			},
+			"run": {},
+		},
+		GlobalFlags: Flags{
+			"-v":    PredictNothing,
+			"-mode": PredictSet("a", "b"),
+		},
+	}
+
+	problems := c.CheckFlags(map[string]*flag.FlagSet{
+		"":      root,
+		"build": build,
+		"test":  flag.NewFlagSet("test", flag.ContinueOnError),
+	})
+
+	want := []string{
+		"flag -missing: flag is not completed",
+		"build: flag -race: boolean flag has a predictor",
+		"build: flag -tags: flag expects a value but has no predictor",
+		"build: flag -stale: flag is not defined by the program",
+		"test: flags are defined for a sub command that is not completed",
+	}
+	wantKinds := []ProblemKind{MissingFlag, FlagPredictorMismatch, FlagPredictorMismatch, StaleFlag, UnknownCommand}
+	var got []string
+	var gotKinds []ProblemKind
+	for _, p := range problems {
+		got = append(got, p.String())
+		gotKinds = append(gotKinds, p.Kind)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems:\n%q\nwant:\n%q", got, want)
+	}
+	if !reflect.DeepEqual(gotKinds, wantKinds) {
+		t.Errorf("got kinds %v, want %v", gotKinds, wantKinds)
+	}
+}
@@ -1,7 +1,11 @@
 // Package main is complete tool for the go command line
 package main
 
-import "github.com/posener/complete"
+import (
+	"regexp"
+
+	"github.com/posener/complete"
+)
 
 var (
 	ellipsis   = complete.PredictSet("./...")
@@ -9,6 +13,8 @@ var (
 	goFiles    = complete.PredictFiles("*.go")
 	anyFile    = complete.PredictFiles("*")
 	anyGo      = complete.PredictOr(goFiles, anyPackage, ellipsis)
+	number     = complete.PredictRegexp(regexp.MustCompile(`^[0-9]+$`), "<number>")
+	duration   = complete.PredictRegexp(regexp.MustCompile(`^([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$`), "<duration e.g. 30s>")
 )
 
 func main() {
@@ -19,7 +25,7 @@ func main() {
 
 			"-a":             complete.PredictNothing,
 			"-n":             complete.PredictNothing,
-			"-p":             complete.PredictAnything,
+			"-p":             number,
 			"-race":          complete.PredictNothing,
 			"-msan":          complete.PredictNothing,
 			"-v":             complete.PredictNothing,
@@ -55,14 +61,14 @@ func main() {
 
 			"-bench":     predictBenchmark,
 			"-benchtime": complete.PredictAnything,
-			"-count":     complete.PredictAnything,
+			"-count":     number,
 			"-cover":     complete.PredictNothing,
 			"-covermode": complete.PredictSet("set", "count", "atomic"),
 			"-coverpkg":  complete.PredictDirs("*"),
 			"-cpu":       complete.PredictAnything,
 			"-run":       predictTest,
 			"-short":     complete.PredictNothing,
-			"-timeout":   complete.PredictAnything,
+			"-timeout":   duration,
 
 			"-benchmem":             complete.PredictNothing,
 			"-blockprofile":         complete.PredictFiles("*.out"),
@@ -470,7 +476,12 @@ func main() {
 	}
 
 	env := complete.Command{
-		Args: complete.PredictAnything,
+		Flags: complete.Flags{
+			"-json": complete.PredictNothing,
+			"-u":    complete.PredictNothing,
+			"-w":    complete.PredictNothing,
+		},
+		Args: predictGoEnv,
 	}
 
 	bug := complete.Command{}
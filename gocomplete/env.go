@@ -0,0 +1,48 @@
+package main
+
+import "github.com/posener/complete"
+
+// goEnvNames are the variables that go env prints, and the variables in the
+// environment that start with "GO".
+var goEnvNames = complete.PredictFunc(func(a complete.Args) []string {
+	names := []string{
+		"AR", "CC", "CGO_CFLAGS", "CGO_CPPFLAGS", "CGO_CXXFLAGS", "CGO_ENABLED",
+		"CGO_FFLAGS", "CGO_LDFLAGS", "CXX", "GCCGO", "GO111MODULE", "GOARCH",
+		"GOBIN", "GOCACHE", "GOENV", "GOEXE", "GOFLAGS", "GOHOSTARCH",
+		"GOHOSTOS", "GOINSECURE", "GOMOD", "GONOPROXY", "GONOSUMDB", "GOOS",
+		"GOPATH", "GOPRIVATE", "GOPROXY", "GOROOT", "GOSUMDB", "GOTMPDIR",
+		"GOTOOLDIR", "PKG_CONFIG",
+	}
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+	for _, name := range complete.PredictEnvNames("GO").Predict(a) {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+})
+
+// goEnvValues predicts the values of the go env variables that have a known
+// set of values. Other variables are completed with their current value.
+var goEnvValues = map[string]complete.Predictor{
+	"CGO_ENABLED": complete.PredictSet("0", "1"),
+	"GO111MODULE": complete.PredictSet("on", "off", "auto"),
+	"GOARCH": complete.PredictSet("386", "amd64", "arm", "arm64", "mips", "mips64",
+		"mips64le", "mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"),
+	"GOOS": complete.PredictSet("aix", "android", "darwin", "dragonfly", "freebsd",
+		"illumos", "js", "linux", "netbsd", "openbsd", "plan9", "solaris", "windows"),
+}
+
+// predictGoEnv predicts the arguments of go env: assignments of the form
+// "NAME=value" after -w, and names otherwise.
+var predictGoEnv = complete.PredictFunc(func(a complete.Args) []string {
+	for _, arg := range a.Completed {
+		if arg == "-w" {
+			return complete.PredictEnv(goEnvNames, goEnvValues).Predict(a)
+		}
+	}
+	return goEnvNames.Predict(a)
+})
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/posener/complete"
+)
+
+func TestPredictGoEnv(t *testing.T) {
+	t.Parallel()
+
+	gogo := complete.Command{
+		Sub: complete.Commands{
+			"env": {Flags: complete.Flags{"-w": complete.PredictNothing}, Args: predictGoEnv},
+		},
+	}
+	cmp := complete.New("go", gogo)
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "go env GOAR", want: "GOARCH"},
+		{line: "go env -w GOAR", want: "GOARCH="},
+		{line: "go env -w GOOS=li", want: "linux"},
+		{line: "go env -w CGO_ENABLED=1", want: "1"},
+	}
+
+	for _, tt := range tests {
+		result, err := cmp.CompleteLine(tt.line, len(tt.line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Matches) != 1 || result.Matches[0] != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.line, result.Matches, tt.want)
+		}
+	}
+}
@@ -9,11 +9,11 @@ import (
 	"github.com/posener/complete"
 )
 
-func functionsInFile(path string, regexp *regexp.Regexp) (tests []string) {
+func functionsInFile(l complete.Logger, path string, regexp *regexp.Regexp) (tests []string) {
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, path, nil, 0)
 	if err != nil {
-		complete.Log("Failed parsing %s: %s", path, err)
+		l.Logf(complete.LevelError, "Failed parsing %s: %s", path, err)
 		return nil
 	}
 	for _, d := range f.Decls {
@@ -30,7 +30,7 @@ func predictPackages(a complete.Args) (prediction []string) {
 }
 
 func predictLocalAndSystem(a complete.Args) []string {
-	localDirs := complete.PredictFilesSet(listPackages(a.Directory())).Predict(a)
+	localDirs := complete.PredictFilesSet(listPackages(a.Logger(), a.Dir, a.Directory())).Predict(a)
 	// System directories are not actual file names, for example: 'github.com/posener/complete' could
 	// be the argument, but the actual filename is in $GOPATH/src/github.com/posener/complete'. this
 	// is the reason to use the PredictSet and not the PredictDirs in this case.
@@ -40,12 +40,13 @@ func predictLocalAndSystem(a complete.Args) []string {
 }
 
 // listPackages looks in current pointed dir and in all it's direct sub-packages
-// and return a list of paths to go packages.
-func listPackages(dir string) (directories []string) {
+// and return a list of paths to go packages. A relative dir is relative to the
+// working directory wd, or to the current directory if wd is empty.
+func listPackages(l complete.Logger, wd, dir string) (directories []string) {
 	// add subdirectories
-	files, err := ioutil.ReadDir(dir)
+	files, err := ioutil.ReadDir(inDir(wd, dir))
 	if err != nil {
-		complete.Log("failed reading directory %s: %s", dir, err)
+		l.Logf(complete.LevelError, "failed reading directory %s: %s", dir, err)
 		return
 	}
 
@@ -60,16 +61,24 @@ func listPackages(dir string) (directories []string) {
 
 	// import packages according to given paths
 	for _, p := range paths {
-		pkg, err := build.ImportDir(p, 0)
+		_, err := build.ImportDir(inDir(wd, p), 0)
 		if err != nil {
-			complete.Log("failed importing directory %s: %s", p, err)
+			l.Logf(complete.LevelError, "failed importing directory %s: %s", p, err)
 			continue
 		}
-		directories = append(directories, pkg.Dir)
+		directories = append(directories, p)
 	}
 	return
 }
 
+// inDir returns the path of a relative path in the working directory wd.
+func inDir(wd, path string) string {
+	if wd == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(wd, path)
+}
+
 func systemDirs(dir string) (directories []string) {
 	// get all paths from GOPATH environment variable and use their src directory
 	paths := findGopath()
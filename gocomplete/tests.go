@@ -21,19 +21,20 @@ var (
 // test names use 'Benchmark'
 func funcPredict(funcRegexp *regexp.Regexp) complete.Predictor {
 	return complete.PredictFunc(func(a complete.Args) []string {
-		return funcNames(funcRegexp)
+		return funcNames(a.Logger(), a.Dir, funcRegexp)
 	})
 }
 
-// get all test names in current directory
-func funcNames(funcRegexp *regexp.Regexp) (tests []string) {
-	filepath.Walk("./", func(path string, info os.FileInfo, err error) error {
+// get all test names in the working directory dir, or in the current
+// directory if it is empty
+func funcNames(l complete.Logger, dir string, funcRegexp *regexp.Regexp) (tests []string) {
+	filepath.Walk(inDir(dir, "./"), func(path string, info os.FileInfo, err error) error {
 		// if not a test file, skip
 		if !strings.HasSuffix(path, "_test.go") {
 			return nil
 		}
 		// inspect test file and append all the test names
-		tests = append(tests, functionsInFile(path, funcRegexp)...)
+		tests = append(tests, functionsInFile(l, path, funcRegexp)...)
 		return nil
 	})
 	return
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
@@ -20,7 +22,7 @@ func TestPredictions(t *testing.T) {
 		{
 			name:      "predict tests ok",
 			predictor: predictTest,
-			want:      []string{"TestPredictions", "Example"},
+			want:      []string{"TestPredictions", "TestPredictTest_Dir", "TestPredictGoEnv", "Example"},
 		},
 		{
 			name:      "predict benchmark ok",
@@ -40,6 +42,25 @@ func TestPredictions(t *testing.T) {
 	}
 }
 
+func TestPredictTest_Dir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "gocomplete-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "a_test.go"), []byte("package a\n\nfunc TestA(t *testing.T) {}\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := predictTest.Predict(complete.Args{Dir: dir})
+	if want := []string{"TestA"}; !equal(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
 func BenchmarkFake(b *testing.B) {}
 
 func Example() {
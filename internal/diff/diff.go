@@ -0,0 +1,169 @@
+// Package diff computes line based unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines that are shown around changes.
+const context = 3
+
+// Unified returns the unified diff between the old and new texts, with the
+// given file names in the header. It returns an empty string if the texts
+// are equal.
+func Unified(oldName, newName, old, new string) string {
+	if old == new {
+		return ""
+	}
+	a, b := lines(old), lines(new)
+	ops := compare(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(ops) {
+		writeHunk(&sb, a, b, h)
+	}
+	return sb.String()
+}
+
+// lines splits a text to lines, each line keeps its line ending.
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	l := strings.SplitAfter(s, "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
+
+type opKind int
+
+const (
+	equal opKind = iota
+	remove
+	insert
+)
+
+// op is an edit operation on a single line, i is the index of the line in
+// the old text, and j is its index in the new text.
+type op struct {
+	kind opKind
+	i, j int
+}
+
+// compare returns the edit operations that transform a to b, according to
+// their longest common subsequence.
+func compare(a, b []string) []op {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{equal, i, j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{remove, i, j})
+			i++
+		default:
+			ops = append(ops, op{insert, i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// hunks groups the operations to hunks of changes with their context.
+func hunks(ops []op) [][]op {
+	var (
+		result [][]op
+		start  = -1
+		end    = -1
+	)
+	for k, o := range ops {
+		if o.kind == equal {
+			continue
+		}
+		from := k - context
+		if from < 0 {
+			from = 0
+		}
+		if start != -1 && from > end {
+			result = append(result, ops[start:end])
+			start = -1
+		}
+		if start == -1 {
+			start = from
+		}
+		end = k + context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+	}
+	if start != -1 {
+		result = append(result, ops[start:end])
+	}
+	return result
+}
+
+func writeHunk(sb *strings.Builder, a, b []string, h []op) {
+	var oldLen, newLen int
+	for _, o := range h {
+		if o.kind != insert {
+			oldLen++
+		}
+		if o.kind != remove {
+			newLen++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(h[0].i, oldLen), hunkRange(h[0].j, newLen))
+	for _, o := range h {
+		var prefix, line string
+		switch o.kind {
+		case equal:
+			prefix, line = " ", a[o.i]
+		case remove:
+			prefix, line = "-", a[o.i]
+		case insert:
+			prefix, line = "+", b[o.j]
+		}
+		sb.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the range of lines of a hunk, start is zero based.
+func hunkRange(start, length int) string {
+	switch length {
+	case 0:
+		// An empty range refers to the line before it.
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
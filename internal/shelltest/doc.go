@@ -0,0 +1,8 @@
+// Package shelltest runs real shells in a pseudo terminal to test the
+// completion scripts that cmd/install installs.
+//
+// Every shell runs with a throwaway HOME, in which the completion of a test
+// command is installed, and is driven by keystrokes, including TAB, while
+// its output is inspected. Shells that are not installed are skipped.
+// The harness is only supported on linux.
+package shelltest
@@ -0,0 +1,219 @@
+//go:build linux
+// +build linux
+
+package shelltest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Prompt is the prompt of the shells, it is printed when a shell is ready
+// for input.
+const Prompt = "shelltest$ "
+
+// timeout is the time to wait for an expected output.
+const timeout = 10 * time.Second
+
+// config is the configuration of a shell, that is written to its throwaway
+// HOME before the completion is installed.
+type config struct {
+	// args are the arguments that start the shell interactively.
+	args []string
+	// rc is the path of the rc file relative to HOME, and its content.
+	rc, content string
+	// target is the path, relative to HOME, that the completion is
+	// installed in, see install.Target.
+	target string
+}
+
+var configs = map[string]config{
+	"bash": {
+		args:    []string{"-i"},
+		rc:      ".bashrc",
+		content: "PS1='" + Prompt + "'\nbind 'set bell-style none'\n",
+		target:  ".bashrc",
+	},
+	"zsh": {
+		args:    []string{"-i"},
+		rc:      ".zshrc",
+		content: "autoload -U compinit && compinit -u\nPROMPT='" + Prompt + "'\nunsetopt beep\n",
+		target:  ".zshrc",
+	},
+	"fish": {
+		args:    []string{"-i"},
+		rc:      ".config/fish/config.fish",
+		content: "function fish_prompt; echo -n '" + Prompt + "'; end\n",
+		target:  ".config/fish/completions",
+	},
+}
+
+// Shells are the names of the shells that the harness supports.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Home creates a throwaway HOME directory for a shell, with a bin directory
+// that is put in the PATH of the shell, a tmp directory that is its TMPDIR,
+// and the rc file of the shell.
+// It skips the test if the shell is not installed, and returns the HOME
+// directory, and a function that removes it.
+func Home(t testing.TB, shell string) (home string, remove func()) {
+	t.Helper()
+	c, ok := configs[shell]
+	if !ok {
+		t.Fatalf("unsupported shell %s", shell)
+	}
+	if _, err := exec.LookPath(shell); err != nil {
+		t.Skipf("%s is not installed", shell)
+	}
+
+	home, err := ioutil.TempDir("", "shelltest-"+shell+"-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remove = func() { os.RemoveAll(home) }
+	rc := filepath.Join(home, c.rc)
+	if err := os.MkdirAll(filepath.Dir(rc), 0755); err != nil {
+		remove()
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"bin", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(home, dir), 0755); err != nil {
+			remove()
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(rc, []byte(c.content), 0644); err != nil {
+		remove()
+		t.Fatal(err)
+	}
+	return home, remove
+}
+
+// Env returns the environment of the programs that run with the given HOME.
+func Env(home string) []string {
+	var env []string
+	for _, e := range os.Environ() {
+		switch strings.SplitN(e, "=", 2)[0] {
+		case "HOME", "PATH", "TERM", "TMPDIR", "XDG_CONFIG_HOME", "XDG_DATA_HOME", "ZDOTDIR", "BASH_ENV", "ENV",
+			"COMP_LINE", "COMP_POINT", "COMP_SHELL", "COMP_DEBUG", "COMP_TRACE":
+			continue
+		}
+		env = append(env, e)
+	}
+	return append(env,
+		"HOME="+home,
+		"PATH="+filepath.Join(home, "bin")+string(filepath.ListSeparator)+os.Getenv("PATH"),
+		"TERM=xterm",
+		// The binaries in the HOME are not temporary builds, which are not
+		// installed with their path, see install.Install.
+		"TMPDIR="+filepath.Join(home, "tmp"),
+	)
+}
+
+// Target returns the path in the HOME that the completion of the shell is
+// installed in, as an argument of the -completion-target flag. The install
+// commands find the home directory of the user in the user database, and
+// not in the HOME of Env.
+func Target(shell, home string) string {
+	return filepath.Join(home, configs[shell].target)
+}
+
+// Shell is a shell that runs in a pseudo terminal.
+type Shell struct {
+	t   testing.TB
+	cmd *exec.Cmd
+	pty *os.File
+
+	mu  sync.Mutex
+	out bytes.Buffer
+	// read is the length of the output that was consumed by Expect.
+	read int
+	done chan struct{}
+}
+
+// Start starts an interactive shell with the given HOME, as created by
+// Home, and waits for its prompt.
+func Start(t testing.TB, shell, home string) *Shell {
+	t.Helper()
+	master, slave, err := openPty()
+	if err != nil {
+		t.Fatalf("opening pty: %v", err)
+	}
+	defer slave.Close()
+
+	cmd := exec.Command(shell, configs[shell].args...)
+	cmd.Dir = home
+	cmd.Env = Env(home)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		t.Fatalf("starting %s: %v", shell, err)
+	}
+
+	s := &Shell{t: t, cmd: cmd, pty: master, done: make(chan struct{})}
+	go s.readLoop()
+	s.Expect(Prompt)
+	return s
+}
+
+func (s *Shell) readLoop() {
+	defer close(s.done)
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.pty.Read(buf)
+		s.mu.Lock()
+		s.out.Write(buf[:n])
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Send sends keystrokes to the shell, "\t" is a TAB and "\r" is Enter.
+func (s *Shell) Send(keys string) {
+	s.t.Helper()
+	if _, err := s.pty.Write([]byte(keys)); err != nil {
+		s.t.Fatalf("sending %q: %v", keys, err)
+	}
+}
+
+// Expect waits until the shell outputs text, after the output that was
+// consumed by previous calls, and consumes the output up to the text.
+func (s *Shell) Expect(text string) {
+	s.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		s.mu.Lock()
+		out := s.out.String()[s.read:]
+		i := strings.Index(out, text)
+		if i >= 0 {
+			s.read += i + len(text)
+		}
+		s.mu.Unlock()
+		if i >= 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatalf("timeout waiting for %q, got output:\n%s", text, out)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Close kills the shell.
+func (s *Shell) Close() {
+	s.cmd.Process.Kill()
+	s.cmd.Wait()
+	s.pty.Close()
+	<-s.done
+}
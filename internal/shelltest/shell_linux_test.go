@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package shelltest
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShells(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs real shells")
+	}
+	for _, shell := range Shells {
+		for _, lookup := range []bool{false, true} {
+			shell, lookup := shell, lookup
+			name := shell + "/path"
+			if lookup {
+				name = shell + "/lookup"
+			}
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				home, remove := Home(t, shell)
+				defer remove()
+				install(t, shell, home, lookup)
+
+				s := Start(t, shell, home)
+				defer s.Close()
+
+				// A single candidate is inserted.
+				s.Send("shelltest-cmd al\t o\t\r")
+				s.Expect("args: alpha one.")
+				s.Expect(Prompt)
+
+				// Multiple candidates are listed.
+				s.Send("shelltest-cmd alpha \t\t")
+				s.Expect("one")
+				s.Expect("two")
+			})
+		}
+	}
+}
+
+// install builds the test command into the bin directory of the HOME, and
+// installs its completion in the shell, with the absolute path of the
+// binary, or with a $PATH lookup.
+func install(t *testing.T, shell, home string, lookup bool) {
+	t.Helper()
+	bin := filepath.Join(home, "bin", "shelltest-cmd")
+	if out, err := exec.Command("go", "build", "-o", bin, "./testdata/cmd").CombinedOutput(); err != nil {
+		t.Fatalf("building test command: %v\n%s", err, out)
+	}
+
+	args := []string{"-install", "-y", "-completion-shell", shell, "-completion-target", Target(shell, home)}
+	if lookup {
+		args = append(args, "-completion-lookup")
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Env = Env(home)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("installing completion: %v\n%s", err, out)
+	}
+	if rc, err := ioutil.ReadFile(Target(shell, home)); err == nil && lookup == strings.Contains(string(rc), bin) {
+		t.Fatalf("installed completion, lookup %v:\n%s", lookup, rc)
+	}
+}
@@ -0,0 +1,25 @@
+// Command shelltest-cmd is completed by the shells in the tests.
+// When it is run, it prints its arguments, so the tests can see what the
+// shell completed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+func main() {
+	cmp := complete.New("shelltest-cmd", complete.Command{
+		Sub: complete.Commands{
+			"alpha": {Args: complete.PredictSet("one", "two")},
+			"beta":  {},
+		},
+	})
+	if cmp.Run() {
+		return
+	}
+	fmt.Printf("args: %s.\n", strings.Join(flag.Args(), " "))
+}
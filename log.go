@@ -10,7 +10,13 @@ import (
 // since complete is running on tab completion, it is nice to
 // have logs to the stderr (when writing your own completer)
 // to write logs, set the COMP_DEBUG environment variable and
-// use complete.Log in the complete program
+// use complete.Log in the complete program.
+// For a structured trace of how completions are computed, set the
+// COMP_TRACE environment variable to the path of a file that the trace
+// is appended to.
+//
+// Log is the default Logger of a completion. To route the logs of a
+// completion elsewhere, set Complete.Logger instead.
 var Log = getLogger()
 
 func getLogger() func(format string, args ...interface{}) {
@@ -20,3 +26,48 @@ func getLogger() func(format string, args ...interface{}) {
 	}
 	return log.New(logfile, "complete ", log.Flags()).Printf
 }
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	// LevelDebug messages tell how the completion was computed.
+	LevelDebug Level = iota
+	// LevelError messages tell about failures. The completion continues
+	// with the options that could be predicted.
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Logger logs the messages of a completion.
+// Completion runs in the completion machinery of the shell, which reads
+// the completion options from the standard output, so a logger should not
+// write to the standard output.
+type Logger interface {
+	Logf(level Level, format string, args ...interface{})
+}
+
+// LoggerFunc is a Logger that logs messages of all levels with a printf
+// like function.
+type LoggerFunc func(format string, args ...interface{})
+
+// Logf logs the message with f.
+func (f LoggerFunc) Logf(level Level, format string, args ...interface{}) {
+	f(format, args...)
+}
+
+// defaultLogger logs with Log, so programs that replaced Log keep getting
+// the logs.
+var defaultLogger Logger = LoggerFunc(func(format string, args ...interface{}) {
+	Log(format, args...)
+})
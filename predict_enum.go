@@ -0,0 +1,62 @@
+package complete
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// Enum is implemented by flag values that can list the values that they
+// accept, so their flags can be completed with PredictFlagValue.
+type Enum interface {
+	Values() []string
+}
+
+// PredictEnum expects one of the values in a slice, such as a slice of
+// the constants of an enumeration type. The values are completed by their
+// text: the encoding.TextMarshaler text, which is what UnmarshalText
+// usually parses, the fmt.Stringer string, or their default format.
+// It panics if values is not a slice, or if a value fails to marshal.
+func PredictEnum(values interface{}) Predictor {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic(fmt.Sprintf("complete: PredictEnum of %T, which is not a slice", values))
+	}
+	options := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		option, err := enumText(v.Index(i).Interface())
+		if err != nil {
+			panic(fmt.Sprintf("complete: PredictEnum value %v: %v", v.Index(i), err))
+		}
+		options = append(options, option)
+	}
+	return PredictSet(options...)
+}
+
+func enumText(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		return string(text), err
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// PredictFlagValue predicts the value of a flag according to its flag.Value.
+// Values that implement Enum are completed with the values that they list,
+// boolean flags expect no value, and other values expect anything.
+func PredictFlagValue(v flag.Value) Predictor {
+	if e, ok := v.(Enum); ok {
+		return PredictFunc(func(Args) []string {
+			return e.Values()
+		})
+	}
+	if b, ok := v.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return PredictNothing
+	}
+	return PredictAnything
+}
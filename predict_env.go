@@ -0,0 +1,47 @@
+package complete
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// PredictEnvNames predicts the names of the variables in the current
+// environment that start with prefix, such as "GO". An empty prefix
+// predicts all the names. To predict a known list of names, use PredictSet.
+func PredictEnvNames(prefix string) Predictor {
+	return PredictFunc(func(Args) []string {
+		var names []string
+		for _, kv := range os.Environ() {
+			name := kv
+			if i := strings.Index(kv, "="); i >= 0 {
+				name = kv[:i]
+			}
+			if name != "" && strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	})
+}
+
+// PredictEnv predicts an environment variable assignment of the form
+// "NAME=value", such as in "env GOOS=linux" or "-env GOOS=linux". Like
+// PredictKeyValue, it completes the name with the names predictor, followed
+// by "=", and the value with the predictor of the name in values. Names that
+// are not in values are completed with their current value in the
+// environment.
+func PredictEnv(names Predictor, values map[string]Predictor) Predictor {
+	return keyValue(names, func(name string) Predictor {
+		if p, ok := values[name]; ok {
+			return p
+		}
+		return PredictFunc(func(Args) []string {
+			if value := os.Getenv(name); value != "" {
+				return []string{value}
+			}
+			return nil
+		})
+	})
+}
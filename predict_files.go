@@ -37,7 +37,7 @@ func files(pattern string, allowFiles bool) PredictFunc {
 		}
 
 		// only try deeper, if the one item is a directory
-		if stat, err := os.Stat(prediction[0]); err != nil || !stat.IsDir() {
+		if stat, err := os.Stat(resolve(a.Dir, prediction[0])); err != nil || !stat.IsDir() {
 			return
 		}
 
@@ -51,8 +51,8 @@ func predictFiles(a Args, pattern string, allowFiles bool) []string {
 		return nil
 	}
 
-	dir := directory(a.Last)
-	files := listFiles(dir, pattern, allowFiles)
+	dir := directory(a.Dir, a.Last)
+	files := listFiles(a.Dir, dir, pattern, allowFiles)
 
 	// add dir if match
 	files = append(files, dir)
@@ -60,15 +60,16 @@ func predictFiles(a Args, pattern string, allowFiles bool) []string {
 	return PredictFilesSet(files).Predict(a)
 }
 
-// directory gives the directory of the given partial path
-// in case that it is not, we fall back to the current directory.
-func directory(path string) string {
-	if info, err := os.Stat(path); err == nil && info.IsDir() {
-		return fixPathForm(path, path)
+// directory gives the directory of the given partial path, relative to the
+// working directory wd, in case that it is not, we fall back to the current
+// directory.
+func directory(wd, path string) string {
+	if info, err := os.Stat(resolve(wd, path)); err == nil && info.IsDir() {
+		return fixPathForm(wd, path, path)
 	}
 	dir := filepath.Dir(path)
-	if info, err := os.Stat(dir); err == nil && info.IsDir() {
-		return fixPathForm(path, dir)
+	if info, err := os.Stat(resolve(wd, dir)); err == nil && info.IsDir() {
+		return fixPathForm(wd, path, dir)
 	}
 	return "./"
 }
@@ -78,7 +79,7 @@ func PredictFilesSet(files []string) PredictFunc {
 	return func(a Args) (prediction []string) {
 		// add all matching files to prediction
 		for _, f := range files {
-			f = fixPathForm(a.Last, f)
+			f = fixPathForm(a.Dir, a.Last, f)
 
 			// test matching of file to the argument
 			if matchFile(f, a.Last) {
@@ -89,31 +90,28 @@ func PredictFilesSet(files []string) PredictFunc {
 	}
 }
 
-func listFiles(dir, pattern string, allowFiles bool) []string {
-	// set of all file names
-	m := map[string]bool{}
-
-	// list files
-	if files, err := filepath.Glob(filepath.Join(dir, pattern)); err == nil {
-		for _, f := range files {
-			if stat, err := os.Stat(f); err != nil || stat.IsDir() || allowFiles {
-				m[f] = true
-			}
-		}
+// listFiles lists the files in dir, relative to the working directory wd,
+// that match the pattern, and the sub directories of dir.
+func listFiles(wd, dir, pattern string, allowFiles bool) []string {
+	entries, err := ioutil.ReadDir(resolve(wd, dir))
+	if err != nil {
+		return nil
 	}
 
-	// list directories
-	if dirs, err := ioutil.ReadDir(dir); err == nil {
-		for _, d := range dirs {
-			if d.IsDir() {
-				m[filepath.Join(dir, d.Name())] = true
-			}
+	list := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := filepath.Join(dir, e.Name())
+		if e.IsDir() {
+			list = append(list, name)
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, e.Name()); !ok {
+			continue
+		}
+		// Symbolic links to directories are directories.
+		if stat, err := os.Stat(resolve(wd, name)); err != nil || stat.IsDir() || allowFiles {
+			list = append(list, name)
 		}
-	}
-
-	list := make([]string, 0, len(m))
-	for k := range m {
-		list = append(list, k)
 	}
 	return list
 }
@@ -135,21 +133,21 @@ func matchFile(file, prefix string) bool {
 }
 
 // fixPathForm changes a file name to a relative name
-func fixPathForm(last string, file string) string {
+func fixPathForm(wd, last string, file string) string {
 	// get wording directory for relative name
-	workDir, err := os.Getwd()
+	workDir, err := workDir(wd)
 	if err != nil {
 		return file
 	}
 
-	abs, err := filepath.Abs(file)
-	if err != nil {
-		return file
+	abs := file
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(workDir, file)
 	}
 
 	// if last is absolute, return path as absolute
 	if filepath.IsAbs(last) {
-		return fixDirPath(abs)
+		return fixDirPath(wd, abs)
 	}
 
 	rel, err := filepath.Rel(workDir, abs)
@@ -162,13 +160,33 @@ func fixPathForm(last string, file string) string {
 		rel = "./" + rel
 	}
 
-	return fixDirPath(rel)
+	return fixDirPath(wd, rel)
 }
 
-func fixDirPath(path string) string {
-	info, err := os.Stat(path)
+// fixDirPath adds a slash to a path of a directory, relative to the working
+// directory wd.
+func fixDirPath(wd, path string) string {
+	info, err := os.Stat(resolve(wd, path))
 	if err == nil && info.IsDir() && !strings.HasSuffix(path, "/") {
 		path += "/"
 	}
 	return path
 }
+
+// workDir returns the absolute path of the working directory wd, or of the
+// current working directory if wd is empty.
+func workDir(wd string) (string, error) {
+	if wd == "" {
+		return os.Getwd()
+	}
+	return filepath.Abs(wd)
+}
+
+// resolve returns the path of a file name relative to the working directory
+// wd. If wd is empty, the name is relative to the current working directory.
+func resolve(wd, name string) string {
+	if wd == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(wd, name)
+}
@@ -0,0 +1,87 @@
+package complete
+
+import "strings"
+
+// PredictList predicts a list of elements separated by sep, such as
+// "a,b,c" in "-tags a,b,c". It completes the element after the last
+// separator with the element predictor, keeping the elements before it,
+// and skips elements that are already in the list. Elements of the form
+// "key=value", such as those predicted by PredictKeyValue, are skipped if
+// their key is already in the list. It panics if sep is empty.
+func PredictList(sep string, element Predictor) Predictor {
+	if sep == "" {
+		panic("complete: PredictList with an empty separator")
+	}
+	return PredictFunc(func(a Args) []string {
+		typed := a.value()
+		i := strings.LastIndex(typed, sep)
+		if i < 0 {
+			return element.Predict(a)
+		}
+		// The present elements, and the keys, with the "=", of the present
+		// elements of the form "key=value".
+		present := map[string]bool{}
+		for _, e := range strings.Split(typed[:i], sep) {
+			present[e] = true
+			if j := strings.Index(e, "="); j >= 0 {
+				present[e[:j+1]] = true
+			}
+		}
+
+		// The element predictor completes the element after the separator,
+		// and the shell completes the last word from the last "=".
+		sub := a.withValue(typed[i+len(sep):])
+		prefix := strings.TrimSuffix(a.Last, sub.Last)
+		elementPrefix := strings.TrimSuffix(sub.word, sub.Last)
+
+		var options []string
+		for _, option := range element.Predict(sub) {
+			e := elementPrefix + option
+			if present[e] {
+				continue
+			}
+			options = append(options, prefix+option)
+		}
+		return options
+	})
+}
+
+// PredictKeyValue predicts an element of the form "key=value", such as
+// "env=prod". It completes the key with the keys predictor, followed by
+// "=", and the value with the predictor of the typed key in values.
+// Use it with PredictList for lists like "env=prod,team=x".
+func PredictKeyValue(keys Predictor, values map[string]Predictor) Predictor {
+	return keyValue(keys, func(key string) Predictor { return values[key] })
+}
+
+// keyValue predicts an element of the form "key=value", the value is
+// predicted by the predictor that value returns for the typed key.
+func keyValue(keys Predictor, value func(key string) Predictor) Predictor {
+	return PredictFunc(func(a Args) []string {
+		typed := a.value()
+		i := strings.Index(typed, "=")
+		if i < 0 {
+			var options []string
+			for _, key := range keys.Predict(a) {
+				options = append(options, key+"=")
+			}
+			return options
+		}
+		p := value(typed[:i])
+		if p == nil {
+			return nil
+		}
+		return p.Predict(a.withValue(typed[i+1:]))
+	})
+}
+
+// withValue returns the args with a last word that is the given value, as
+// a part of the typed value.
+func (a Args) withValue(value string) Args {
+	a.word = value
+	a.Last = value
+	if i := strings.LastIndex(value, "="); i >= 0 {
+		a.Last = value[i+1:]
+	}
+	return a
+}
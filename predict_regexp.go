@@ -0,0 +1,108 @@
+package complete
+
+import (
+	"regexp"
+	"regexp/syntax"
+)
+
+// PredictRegexp predicts values that match re in full, such as a number or
+// a duration. The values can't be listed, so it completes nothing, but as
+// long as the typed word can still be completed to a match of re, it shows
+// the hint, such as "<port 1-65535>", in shells that can display messages.
+// When the typed word can't match, it shows nothing.
+func PredictRegexp(re *regexp.Regexp, hint string) Predictor {
+	prog, err := compile(re)
+	return PredictFunc(func(a Args) []string {
+		if err != nil || matchPrefix(prog, a.Last) {
+			a.Hint(hint)
+		}
+		return nil
+	})
+}
+
+// PredictValid predicts values that are checked by a validation function.
+// possible reports if the typed word can be completed to a valid value.
+// Like PredictRegexp, it completes nothing, and shows the hint only when the
+// typed word is possible.
+func PredictValid(possible func(prefix string) bool, hint string) Predictor {
+	return PredictFunc(func(a Args) []string {
+		if possible(a.Last) {
+			a.Hint(hint)
+		}
+		return nil
+	})
+}
+
+func compile(re *regexp.Regexp) (*syntax.Prog, error) {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return syntax.Compile(parsed.Simplify())
+}
+
+// matchPrefix returns true if prefix can be completed to a string that
+// matches prog in full. It runs prog as a nondeterministic automaton on
+// prefix, and returns true if any thread survived. Empty width assertions
+// other than the beginning of the text are assumed to hold, so the result
+// may be a false positive for regular expressions that use them in the
+// middle.
+func matchPrefix(prog *syntax.Prog, prefix string) bool {
+	threads := addThread(prog, nil, map[uint32]bool{}, uint32(prog.Start), true)
+	for _, r := range prefix {
+		var (
+			next    []uint32
+			visited = map[uint32]bool{}
+		)
+		for _, pc := range threads {
+			inst := &prog.Inst[pc]
+			if matchRune(inst, r) {
+				next = addThread(prog, next, visited, inst.Out, false)
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		threads = next
+	}
+	return len(threads) > 0
+}
+
+// addThread adds pc, or the instructions that it leads to without consuming
+// a rune, to the threads. begin tells if no rune was consumed yet.
+func addThread(prog *syntax.Prog, threads []uint32, visited map[uint32]bool, pc uint32, begin bool) []uint32 {
+	if visited[pc] {
+		return threads
+	}
+	visited[pc] = true
+	inst := &prog.Inst[pc]
+	switch inst.Op {
+	case syntax.InstFail:
+		return threads
+	case syntax.InstAlt, syntax.InstAltMatch:
+		threads = addThread(prog, threads, visited, inst.Out, begin)
+		return addThread(prog, threads, visited, inst.Arg, begin)
+	case syntax.InstCapture, syntax.InstNop:
+		return addThread(prog, threads, visited, inst.Out, begin)
+	case syntax.InstEmptyWidth:
+		if syntax.EmptyOp(inst.Arg)&(syntax.EmptyBeginText|syntax.EmptyBeginLine) != 0 && !begin {
+			return threads
+		}
+		return addThread(prog, threads, visited, inst.Out, begin)
+	default:
+		// Instructions that consume a rune, and match.
+		return append(threads, pc)
+	}
+}
+
+func matchRune(inst *syntax.Inst, r rune) bool {
+	switch inst.Op {
+	case syntax.InstRune, syntax.InstRune1:
+		return inst.MatchRune(r)
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return r != '\n'
+	}
+	return false
+}
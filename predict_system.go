@@ -0,0 +1,178 @@
+package complete
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The files that the system predictors read, they are variables so that
+// tests can replace them.
+var (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+	hostsFile  = "/etc/hosts"
+	netDir     = "/sys/class/net"
+	// sshDir returns the directory of the ssh config and known_hosts files.
+	sshDir = func() string {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(home, ".ssh")
+	}
+)
+
+// PredictUsers predicts the sorted names of the local users, from
+// /etc/passwd.
+var PredictUsers = PredictFunc(func(a Args) []string {
+	return unique(readFields(a, passwdFile, ":", func(fields []string) []string {
+		return fields[:1]
+	}))
+})
+
+// PredictGroups predicts the sorted names of the local groups, from
+// /etc/group.
+var PredictGroups = PredictFunc(func(a Args) []string {
+	return unique(readFields(a, groupFile, ":", func(fields []string) []string {
+		return fields[:1]
+	}))
+})
+
+// signals are the standard signal names, without the "SIG" prefix.
+var signals = []string{
+	"HUP", "INT", "QUIT", "ILL", "TRAP", "ABRT", "BUS", "FPE", "KILL", "USR1",
+	"SEGV", "USR2", "PIPE", "ALRM", "TERM", "CHLD", "CONT", "STOP", "TSTP",
+	"TTIN", "TTOU", "URG", "XCPU", "XFSZ", "VTALRM", "PROF", "WINCH", "IO", "SYS",
+}
+
+// PredictSignals predicts the standard signal names, without the "SIG"
+// prefix, such as "TERM" and "KILL".
+var PredictSignals = PredictSet(signals...)
+
+// PredictHosts predicts host names, from /etc/hosts, and from the Host
+// entries of ~/.ssh/config and the hosts in ~/.ssh/known_hosts. Patterns and
+// hashed hosts are skipped.
+var PredictHosts = PredictFunc(func(a Args) []string {
+	var hosts []string
+	hosts = append(hosts, readFields(a, hostsFile, "", func(fields []string) []string {
+		return fields[1:]
+	})...)
+	if dir := sshDir(); dir != "" {
+		hosts = append(hosts, readFields(a, filepath.Join(dir, "config"), "", sshConfigHosts)...)
+		hosts = append(hosts, readFields(a, filepath.Join(dir, "known_hosts"), "", knownHosts)...)
+	}
+	return unique(hosts)
+})
+
+// PredictInterfaces predicts the names of the network interfaces, from
+// /sys/class/net. Interfaces are its directories and symbolic links, other
+// files, such as bonding_masters, are skipped.
+var PredictInterfaces = PredictFunc(func(a Args) []string {
+	entries, err := ioutil.ReadDir(netDir)
+	if err != nil {
+		a.Logger().Logf(LevelDebug, "Failed listing network interfaces: %v", err)
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || e.Mode()&os.ModeSymlink != 0 {
+			names = append(names, e.Name())
+		}
+	}
+	return unique(names)
+})
+
+// readFields reads the lines of a file, without comments and empty lines,
+// splits them to fields by sep, or by white space if sep is empty, and
+// returns the names that the names function selects from the fields.
+func readFields(a Args, path, sep string, names func(fields []string) []string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		a.Logger().Logf(LevelDebug, "Failed reading %s: %v", path, err)
+		return nil
+	}
+	defer f.Close()
+
+	var options []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		var fields []string
+		if sep == "" {
+			fields = strings.Fields(line)
+		} else if strings.TrimSpace(line) != "" {
+			fields = strings.Split(line, sep)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		for _, name := range names(fields) {
+			if name != "" {
+				options = append(options, name)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		a.Logger().Logf(LevelDebug, "Failed reading %s: %v", path, err)
+	}
+	return options
+}
+
+// sshConfigHosts returns the host names of a Host line of an ssh config.
+func sshConfigHosts(fields []string) []string {
+	if !strings.EqualFold(fields[0], "Host") {
+		return nil
+	}
+	var hosts []string
+	for _, host := range fields[1:] {
+		if !strings.ContainsAny(host, "*?!") {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// knownHosts returns the host names of a known_hosts line, which may start
+// with a marker, and lists the hosts separated by commas, possibly with a
+// port in the form "[host]:port". Addresses are skipped, like the addresses
+// of /etc/hosts.
+func knownHosts(fields []string) []string {
+	if strings.HasPrefix(fields[0], "@") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "|") {
+		return nil
+	}
+	var hosts []string
+	for _, host := range strings.Split(fields[0], ",") {
+		if strings.HasPrefix(host, "[") {
+			if i := strings.Index(host, "]"); i >= 0 {
+				host = host[1:i]
+			}
+		}
+		if !strings.ContainsAny(host, "*?!") && net.ParseIP(host) == nil {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// unique returns the sorted strings without duplicates.
+func unique(s []string) []string {
+	sort.Strings(s)
+	var out []string
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
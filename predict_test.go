@@ -1,8 +1,12 @@
 package complete
 
 import (
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"testing"
@@ -269,3 +273,368 @@ func TestMatchFile(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		re     string
+		prefix string
+		want   bool
+	}{
+		{re: `^[0-9]+$`, prefix: "", want: true},
+		{re: `^[0-9]+$`, prefix: "123", want: true},
+		{re: `^[0-9]+$`, prefix: "12a", want: false},
+		{re: `[0-9]+s`, prefix: "30", want: true},
+		{re: `[0-9]+s`, prefix: "30s", want: true},
+		{re: `[0-9]+s`, prefix: "30ss", want: false},
+		{re: `[0-9]+s`, prefix: "s", want: false},
+		{re: `(?i)yes|no`, prefix: "Y", want: true},
+		{re: `(?i)yes|no`, prefix: "nO", want: true},
+		{re: `(?i)yes|no`, prefix: "maybe", want: false},
+		{re: `(a|)*b`, prefix: "aaab", want: true},
+		{re: `a^b`, prefix: "a", want: false},
+		{re: `.*`, prefix: "anything", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.re+"/"+tt.prefix, func(t *testing.T) {
+			prog, err := compile(regexp.MustCompile(tt.re))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchPrefix(prog, tt.prefix); got != tt.want {
+				t.Errorf("matchPrefix(%q, %q) = %v, want %v", tt.re, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredictRegexp_Hints(t *testing.T) {
+	t.Parallel()
+
+	port := PredictRegexp(regexp.MustCompile(`^[0-9]{1,5}$`), "<port 1-65535>")
+	even := PredictValid(func(prefix string) bool {
+		return prefix == "" || strings.Trim(prefix, "02468") == ""
+	}, "<even digits>")
+
+	tests := []struct {
+		p    Predictor
+		last string
+		want []string
+	}{
+		{p: port, last: "", want: []string{"<port 1-65535>"}},
+		{p: port, last: "80", want: []string{"<port 1-65535>"}},
+		{p: port, last: "8o", want: nil},
+		{p: port, last: "123456", want: nil},
+		{p: even, last: "24", want: []string{"<even digits>"}},
+		{p: even, last: "23", want: nil},
+	}
+
+	for _, tt := range tests {
+		var hints []string
+		a := Args{Last: tt.last, hints: &hints}
+		if got := tt.p.Predict(a); len(got) != 0 {
+			t.Errorf("%q: expected no options, got %q", tt.last, got)
+		}
+		if !equalSlices(hints, tt.want) {
+			t.Errorf("%q: got hints %q, want %q", tt.last, hints, tt.want)
+		}
+	}
+}
+
+func TestPredictList(t *testing.T) {
+	t.Parallel()
+
+	c := Command{
+		Flags: Flags{
+			"-tags": PredictList(",", PredictSet("linux", "darwin", "netgo")),
+			"-labels": PredictList(",", PredictKeyValue(
+				PredictSet("env", "team"),
+				map[string]Predictor{
+					"env": PredictSet("prod", "staging"),
+				},
+			)),
+			"-gcflags": PredictKeyValue(PredictSet("all"), map[string]Predictor{
+				"all": PredictSet("-N", "-l"),
+			}),
+		},
+	}
+	cmp := New("cmd", c)
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "cmd -tags ", want: []string{"darwin", "linux", "netgo"}},
+		{line: "cmd -tags li", want: []string{"linux"}},
+		{line: "cmd -tags linux,", want: []string{"linux,darwin", "linux,netgo"}},
+		{line: "cmd -tags linux,netgo,d", want: []string{"linux,netgo,darwin"}},
+		{line: "cmd -tags=linux,", want: []string{"linux,darwin", "linux,netgo"}},
+		{line: "cmd -labels ", want: []string{"env=", "team="}},
+		{line: "cmd -labels env=", want: []string{"prod", "staging"}},
+		{line: "cmd -labels env=p", want: []string{"prod"}},
+		{line: "cmd -labels env=prod,", want: []string{"prod,team="}},
+		{line: "cmd -labels team=x,env=s", want: []string{"staging"}},
+		{line: "cmd -labels=team=x,e", want: []string{"x,env="}},
+		{line: "cmd -labels team=x,other=", want: []string{}},
+		{line: "cmd -gcflags all=", want: []string{"-N", "-l"}},
+		{line: "cmd -gcflags=all=-", want: []string{"-N", "-l"}},
+	}
+
+	for _, tt := range tests {
+		result, err := cmp.CompleteLine(tt.line, len(tt.line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := result.Matches
+		sort.Strings(got)
+		if !equalSlices(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+type color int
+
+func (c color) String() string { return [...]string{"red", "green"}[c] }
+
+type level int
+
+func (l level) MarshalText() ([]byte, error) { return []byte(fmt.Sprintf("level%d", l)), nil }
+
+// enumValue is a flag.Value that lists its allowed values.
+type enumValue struct{ value string }
+
+func (e *enumValue) String() string     { return e.value }
+func (e *enumValue) Set(v string) error { e.value = v; return nil }
+func (e *enumValue) Values() []string   { return []string{"one", "two"} }
+
+func TestPredictEnum(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		p    Predictor
+		want []string
+	}{
+		{name: "stringer", p: PredictEnum([]color{0, 1}), want: []string{"red", "green"}},
+		{name: "text marshaler", p: PredictEnum([]level{1, 2}), want: []string{"level1", "level2"}},
+		{name: "interface slice", p: PredictEnum([]fmt.Stringer{color(1)}), want: []string{"green"}},
+		{name: "ints", p: PredictEnum([]int{1, 2}), want: []string{"1", "2"}},
+		{name: "flag enum", p: PredictFlagValue(&enumValue{}), want: []string{"one", "two"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.Predict(Args{}); !equalSlices(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	v := fs.Bool("b", false, "")
+	if p := PredictFlagValue(fs.Lookup("b").Value); p != nil {
+		t.Errorf("expected bool flag to predict nothing, got %v for %v", p, *v)
+	}
+	fs.String("s", "", "")
+	if p := PredictFlagValue(fs.Lookup("s").Value); p == nil {
+		t.Errorf("expected string flag to expect a value")
+	}
+}
+
+func TestPredictEnv(t *testing.T) {
+	os.Setenv("COMPLETE_TEST_MODE", "fast")
+	os.Setenv("COMPLETE_TEST_LEVEL", "1")
+	defer os.Unsetenv("COMPLETE_TEST_MODE")
+	defer os.Unsetenv("COMPLETE_TEST_LEVEL")
+
+	c := Command{
+		Args: PredictEnv(PredictEnvNames("COMPLETE_TEST_"), map[string]Predictor{
+			"COMPLETE_TEST_LEVEL": PredictSet("1", "2"),
+		}),
+	}
+	cmp := New("cmd", c)
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "cmd ", want: []string{"COMPLETE_TEST_LEVEL=", "COMPLETE_TEST_MODE="}},
+		{line: "cmd COMPLETE_TEST_M", want: []string{"COMPLETE_TEST_MODE="}},
+		{line: "cmd COMPLETE_TEST_MODE=", want: []string{"fast"}},
+		{line: "cmd COMPLETE_TEST_LEVEL=", want: []string{"1", "2"}},
+		{line: "cmd COMPLETE_TEST_OTHER=", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		result, err := cmp.CompleteLine(tt.line, len(tt.line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := result.Matches
+		sort.Strings(got)
+		if !equalSlices(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPredictSystem(t *testing.T) {
+	dir, err := ioutil.TempDir("", "complete-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"passwd":     "# users\nroot:x:0:0:root:/root:/bin/bash\nalice:x:1000:1000::/home/alice:/bin/sh\nroot:x:0:0::/:/bin/sh\n",
+		"group":      "root:x:0:\nwheel:x:10:alice\n",
+		"hosts":      "127.0.0.1 localhost # loopback\n10.0.0.1 db db.local\n",
+		"ssh/config": "Host web *.internal\n  HostName 10.0.0.2\nHost !bad db\n",
+		"ssh/known_hosts": "github.com,140.82.121.4 ssh-ed25519 AAAA\n" +
+			"[git.local]:2222 ssh-rsa AAAA\n[10.0.0.5]:22,::1 ssh-rsa AAAA\n" +
+			"|1|hashed= ssh-rsa AAAA\n@cert-authority *.corp ssh-rsa AAAA\n",
+		"net/eth0/type":       "1",
+		"net/lo/type":         "772",
+		"net/bonding_masters": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// In sysfs, the interfaces are symbolic links to their devices.
+	if err := os.Symlink(filepath.Join(dir, "net", "eth0"), filepath.Join(dir, "net", "wlan0")); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(passwd, group, hosts, net string, ssh func() string) {
+		passwdFile, groupFile, hostsFile, netDir, sshDir = passwd, group, hosts, net, ssh
+	}(passwdFile, groupFile, hostsFile, netDir, sshDir)
+	passwdFile = filepath.Join(dir, "passwd")
+	groupFile = filepath.Join(dir, "group")
+	hostsFile = filepath.Join(dir, "hosts")
+	netDir = filepath.Join(dir, "net")
+	sshDir = func() string { return filepath.Join(dir, "ssh") }
+
+	tests := []struct {
+		name string
+		p    Predictor
+		want []string
+	}{
+		{name: "users", p: PredictUsers, want: []string{"alice", "root"}},
+		{name: "groups", p: PredictGroups, want: []string{"root", "wheel"}},
+		{name: "hosts", p: PredictHosts, want: []string{"db", "db.local", "git.local", "github.com", "localhost", "web"}},
+		{name: "interfaces", p: PredictInterfaces, want: []string{"eth0", "lo", "wlan0"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.Predict(Args{}); !equalSlices(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	c := Command{Flags: Flags{"-signal": PredictSignals, "-user": PredictUsers}}
+	cmp := New("cmd", c)
+	for line, want := range map[string][]string{
+		"cmd -signal TE": {"TERM"},
+		"cmd -user al":   {"alice"},
+	} {
+		result, err := cmp.CompleteLine(line, len(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalSlices(result.Matches, want) {
+			t.Errorf("%q: got %q, want %q", line, result.Matches, want)
+		}
+	}
+}
+
+func TestCompleteLineIn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "complete-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt", "sub/c.go"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmp := New("cmd", Command{Args: PredictFiles("*.txt")})
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "cmd ", want: []string{"./", "a.txt", "sub/"}},
+		{line: "cmd su", want: []string{"sub/", "sub/b.txt"}},
+		{line: "cmd ./sub/", want: []string{"./sub/", "./sub/b.txt"}},
+		{line: "cmd " + dir + "/a", want: []string{dir + "/a.txt"}},
+	}
+	for _, tt := range tests {
+		result, err := cmp.CompleteLineIn(dir, tt.line, len(tt.line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := result.Matches
+		sort.Strings(got)
+		if !equalSlices(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPredictList_Args(t *testing.T) {
+	t.Parallel()
+
+	// Args that are built by hand, rather than parsed from a line.
+	tests := []struct {
+		p    Predictor
+		args Args
+		want []string
+	}{
+		{
+			p:    PredictList(",", PredictSet("a", "b", "c")),
+			args: Args{Last: "a,"},
+			want: []string{"a,b", "a,c"},
+		},
+		{
+			p:    PredictList(",", PredictSet("a", "b", "c")),
+			args: Args{Last: "b,a,"},
+			want: []string{"b,a,c"},
+		},
+		{
+			p:    PredictKeyValue(PredictSet("env"), map[string]Predictor{"env": PredictSet("prod")}),
+			args: Args{Last: "env="},
+			want: []string{"prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.p.Predict(tt.args)
+		sort.Strings(got)
+		if !equalSlices(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.args.Last, got, tt.want)
+		}
+	}
+}
+
+func TestPredictList_EmptySeparator(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an empty separator")
+		}
+	}()
+	PredictList("", PredictSet("a"))
+}
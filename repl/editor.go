@@ -0,0 +1,349 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInterrupt is returned by ReadLine when the user pressed Ctrl-C.
+var ErrInterrupt = errors.New("interrupted")
+
+// Editor is a minimal terminal line editor that completes the typed line
+// using a Completer.
+//
+// It supports cursor movement, history navigation with the arrow keys and
+// completion with the TAB key: the first TAB completes the longest common
+// prefix of the candidates, or lists them if there is no common prefix to
+// add, and consecutive TABs cycle through the candidates.
+type Editor struct {
+	// Prompt is printed at the beginning of every line.
+	Prompt string
+	// Completer completes the typed line, if nil, TAB is ignored.
+	Completer *Completer
+	// In is the input of the editor, defaults to os.Stdin.
+	// If it is a terminal, it is put in raw mode while a line is read.
+	In io.Reader
+	// Out is the output of the editor, defaults to os.Stdout.
+	Out io.Writer
+
+	history []string
+	r       *bufio.Reader
+}
+
+// NewEditor returns an editor that reads lines from the standard input.
+func NewEditor(prompt string, c *Completer) *Editor {
+	return &Editor{Prompt: prompt, Completer: c}
+}
+
+// History returns the lines that were read by the editor.
+func (e *Editor) History() []string {
+	return e.history
+}
+
+// AddHistory adds a line to the history of the editor.
+func (e *Editor) AddHistory(line string) {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+// ReadLine prints the prompt and reads a line from the input.
+// It returns io.EOF if the input ended, or Ctrl-D was pressed on an empty
+// line, and ErrInterrupt if Ctrl-C was pressed.
+func (e *Editor) ReadLine() (string, error) {
+	if e.In == nil {
+		e.In = os.Stdin
+	}
+	if e.Out == nil {
+		e.Out = os.Stdout
+	}
+	if e.r == nil {
+		e.r = bufio.NewReader(e.In)
+	}
+	if f, ok := e.In.(*os.File); ok {
+		if restore, err := makeRaw(f.Fd()); err == nil {
+			defer restore()
+		}
+	}
+
+	l := &lineState{e: e, hist: len(e.history)}
+	l.refresh()
+	for {
+		r, _, err := e.r.ReadRune()
+		if err == io.EOF && len(l.buf) > 0 {
+			l.newline()
+			return l.done(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if r != '\t' {
+			l.tab = nil
+		}
+
+		switch r {
+		case '\r', '\n':
+			l.newline()
+			return l.done(), nil
+		case ctrl('C'):
+			l.write("^C")
+			l.newline()
+			return "", ErrInterrupt
+		case ctrl('D'):
+			if len(l.buf) == 0 {
+				l.newline()
+				return "", io.EOF
+			}
+			l.delete()
+		case '\t':
+			l.complete()
+		case 127, ctrl('H'):
+			l.backspace()
+		case ctrl('A'):
+			l.move(-l.pos)
+		case ctrl('E'):
+			l.move(len(l.buf) - l.pos)
+		case ctrl('B'):
+			l.move(-1)
+		case ctrl('F'):
+			l.move(1)
+		case ctrl('K'):
+			l.buf = l.buf[:l.pos]
+			l.refresh()
+		case ctrl('U'):
+			l.buf = l.buf[l.pos:]
+			l.pos = 0
+			l.refresh()
+		case ctrl('P'):
+			l.historyMove(-1)
+		case ctrl('N'):
+			l.historyMove(1)
+		case 27:
+			l.escape()
+		default:
+			if unicode.IsPrint(r) {
+				l.insert(string(r))
+			}
+		}
+	}
+}
+
+// ctrl returns the control character of the given key.
+func ctrl(key rune) rune {
+	return key & 0x1f
+}
+
+// lineState is the state of a line that is being edited.
+type lineState struct {
+	e   *Editor
+	buf []rune
+	pos int
+
+	// hist is the index in the history of the shown line, and saved is the
+	// edited line that was shown before navigating the history.
+	hist  int
+	saved []rune
+
+	// tab is the state of consecutive TAB presses.
+	tab *tabState
+}
+
+type tabState struct {
+	candidates []string
+	// start is the rune offset where the completed word starts.
+	start int
+	// next is the index of the next candidate to cycle to, it is negative
+	// before the candidates were listed.
+	next int
+}
+
+func (l *lineState) done() string {
+	line := string(l.buf)
+	l.e.AddHistory(line)
+	return line
+}
+
+func (l *lineState) write(s string) {
+	io.WriteString(l.e.Out, s)
+}
+
+func (l *lineState) newline() {
+	l.write("\r\n")
+}
+
+// refresh redraws the prompt and the line, and places the cursor.
+func (l *lineState) refresh() {
+	l.write("\r" + l.e.Prompt + string(l.buf) + "\x1b[K")
+	if back := len(l.buf) - l.pos; back > 0 {
+		l.write(fmt.Sprintf("\x1b[%dD", back))
+	}
+}
+
+func (l *lineState) insert(s string) {
+	rs := []rune(s)
+	buf := make([]rune, 0, len(l.buf)+len(rs))
+	buf = append(buf, l.buf[:l.pos]...)
+	buf = append(buf, rs...)
+	l.buf = append(buf, l.buf[l.pos:]...)
+	l.pos += len(rs)
+	l.refresh()
+}
+
+// replace replaces the runes from start to the cursor with s.
+func (l *lineState) replace(start int, s string) {
+	l.buf = append(l.buf[:start], l.buf[l.pos:]...)
+	l.pos = start
+	l.insert(s)
+}
+
+func (l *lineState) backspace() {
+	if l.pos == 0 {
+		return
+	}
+	l.buf = append(l.buf[:l.pos-1], l.buf[l.pos:]...)
+	l.pos--
+	l.refresh()
+}
+
+func (l *lineState) delete() {
+	if l.pos == len(l.buf) {
+		return
+	}
+	l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+	l.refresh()
+}
+
+func (l *lineState) move(n int) {
+	pos := l.pos + n
+	if pos < 0 || pos > len(l.buf) {
+		return
+	}
+	l.pos = pos
+	l.refresh()
+}
+
+func (l *lineState) historyMove(n int) {
+	hist := l.hist + n
+	if hist < 0 || hist > len(l.e.history) {
+		return
+	}
+	if l.hist == len(l.e.history) {
+		l.saved = l.buf
+	}
+	l.hist = hist
+	if hist == len(l.e.history) {
+		l.buf = l.saved
+	} else {
+		l.buf = []rune(l.e.history[hist])
+	}
+	l.pos = len(l.buf)
+	l.refresh()
+}
+
+// escape handles the escape sequences of the arrow, home, end and delete
+// keys.
+func (l *lineState) escape() {
+	r, _, err := l.e.r.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return
+	}
+	r, _, err = l.e.r.ReadRune()
+	if err != nil {
+		return
+	}
+	switch r {
+	case 'A':
+		l.historyMove(-1)
+	case 'B':
+		l.historyMove(1)
+	case 'C':
+		l.move(1)
+	case 'D':
+		l.move(-1)
+	case 'H':
+		l.move(-l.pos)
+	case 'F':
+		l.move(len(l.buf) - l.pos)
+	case '3':
+		if r, _, err := l.e.r.ReadRune(); err == nil && r == '~' {
+			l.delete()
+		}
+	}
+}
+
+// complete handles a TAB press.
+func (l *lineState) complete() {
+	if l.e.Completer == nil {
+		return
+	}
+
+	// Consecutive TAB presses cycle through the candidates.
+	if l.tab != nil {
+		t := l.tab
+		if t.next < 0 {
+			l.list(t.candidates)
+			t.next = 0
+			return
+		}
+		l.replace(t.start, t.candidates[t.next])
+		t.next = (t.next + 1) % len(t.candidates)
+		return
+	}
+
+	line := string(l.buf[:l.pos])
+	candidates, start := l.e.Completer.Complete(line, len(line))
+	sort.Strings(candidates)
+	start = utf8.RuneCountInString(line[:start])
+	word := string(l.buf[start:l.pos])
+
+	switch len(candidates) {
+	case 0:
+		l.write("\a")
+	case 1:
+		c := candidates[0]
+		if !strings.HasSuffix(c, "/") && !strings.HasSuffix(c, "=") {
+			c += " "
+		}
+		l.replace(start, c)
+	default:
+		l.tab = &tabState{candidates: candidates, start: start, next: -1}
+		prefix := commonPrefix(candidates)
+		if len(prefix) > len(word) {
+			l.replace(start, prefix)
+			return
+		}
+		l.list(candidates)
+		l.tab.next = 0
+	}
+}
+
+// list prints the candidates below the line.
+func (l *lineState) list(candidates []string) {
+	l.newline()
+	l.write(strings.Join(candidates, "  "))
+	l.newline()
+	l.refresh()
+}
+
+func commonPrefix(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	prefix := s[0]
+	for _, c := range s[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
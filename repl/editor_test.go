@@ -0,0 +1,89 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor_ReadLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "status\r", want: "status"},
+		{name: "backspace", input: "statux\x7fs\r", want: "status"},
+		{name: "move and insert", input: "sttus\x1b[D\x1b[D\x1b[Da\r", want: "status"},
+		{name: "kill to end", input: "status -x\x01\x06\x06\x06\x06\x06\x06\x0b\r", want: "status"},
+		{name: "complete single", input: "st\t\r", want: "status "},
+		{name: "complete flag value", input: "deploy -env p\t\r", want: "deploy -env prod "},
+		{name: "complete common prefix", input: "deploy -env \t\r", want: "deploy -env "},
+		{name: "cycle", input: "deploy -env \t\t\t\r", want: "deploy -env staging"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Editor{
+				Prompt:    "> ",
+				Completer: New(testCommand),
+				In:        strings.NewReader(tt.input),
+				Out:       &bytes.Buffer{},
+			}
+			got, err := e.ReadLine()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEditor_ListCandidates(t *testing.T) {
+	t.Parallel()
+	out := &bytes.Buffer{}
+	e := &Editor{
+		Completer: New(testCommand),
+		In:        strings.NewReader("\t\r"),
+		Out:       out,
+	}
+	_, err := e.ReadLine()
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "deploy  status")
+}
+
+func TestEditor_History(t *testing.T) {
+	t.Parallel()
+	e := &Editor{
+		In:  strings.NewReader("first\rsecond\r\x1b[A\x1b[A\r\x10\x0e\r"),
+		Out: &bytes.Buffer{},
+	}
+
+	for _, want := range []string{"first", "second", "first", ""} {
+		got, err := e.ReadLine()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	assert.Equal(t, []string{"first", "second", "first"}, e.History())
+}
+
+func TestEditor_EOF(t *testing.T) {
+	t.Parallel()
+
+	e := &Editor{In: strings.NewReader("\x04"), Out: &bytes.Buffer{}}
+	_, err := e.ReadLine()
+	assert.Equal(t, io.EOF, err)
+
+	e = &Editor{In: strings.NewReader("abc"), Out: &bytes.Buffer{}}
+	got, err := e.ReadLine()
+	require.NoError(t, err)
+	assert.Equal(t, "abc", got)
+
+	e = &Editor{In: strings.NewReader("abc\x03"), Out: &bytes.Buffer{}}
+	_, err = e.ReadLine()
+	assert.Equal(t, ErrInterrupt, err)
+}
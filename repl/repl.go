@@ -0,0 +1,70 @@
+// Package repl adapts a complete.Command to completion callbacks of line
+// editors, so that interactive prompts can reuse the same completion that
+// the command has in the shell.
+//
+// The Completer can be plugged into third party line editors, and the Editor
+// is a minimal terminal line editor that uses it directly.
+package repl
+
+import (
+	"unicode/utf8"
+
+	"github.com/posener/complete"
+)
+
+// Completer completes lines typed in an interactive prompt according to
+// a command tree.
+// Lines typed in the prompt are treated as the arguments of the command,
+// without the command name itself.
+type Completer struct {
+	cmp *complete.Complete
+}
+
+// New returns a Completer for the given command tree.
+func New(command complete.Command) *Completer {
+	return &Completer{cmp: complete.New("", command)}
+}
+
+// Complete returns the completion candidates for the given line, where pos
+// is the byte offset of the cursor in the line. It also returns the byte
+// offset in line where the completed word starts: each candidate should
+// replace line[start:pos].
+func (c *Completer) Complete(line string, pos int) (candidates []string, start int) {
+	if pos < 0 || pos > len(line) {
+		pos = len(line)
+	}
+	// The completed command line contains the command name as the first
+	// argument, add a placeholder for it.
+	const name = "repl "
+	result, err := c.cmp.CompleteLine(name+line, len(name)+pos)
+	if err != nil {
+		c.cmp.Logf(complete.LevelError, "Failed completing line %q: %v", line, err)
+		return nil, pos
+	}
+	return result.Matches, pos - len(result.Args.Last)
+}
+
+// Do implements the AutoCompleter interface of github.com/chzyer/readline.
+// It returns the suffixes that should be added to the typed word, and the
+// number of runes of the word that were already typed.
+func (c *Completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
+	if pos < 0 || pos > len(line) {
+		pos = len(line)
+	}
+	s := string(line[:pos])
+	candidates, start := c.Complete(s, len(s))
+	for _, candidate := range candidates {
+		newLine = append(newLine, []rune(candidate[len(s)-start:]))
+	}
+	return newLine, utf8.RuneCountInString(s[start:])
+}
+
+// WordCompleter implements the WordCompleter function of
+// github.com/peterh/liner. pos is the byte offset of the cursor in line.
+func (c *Completer) WordCompleter(line string, pos int) (head string, completions []string, tail string) {
+	if pos < 0 || pos > len(line) {
+		pos = len(line)
+	}
+	completions, start := c.Complete(line, pos)
+	return line[:start], completions, line[pos:]
+}
@@ -0,0 +1,70 @@
+package repl
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+)
+
+var testCommand = complete.Command{
+	Sub: complete.Commands{
+		"deploy": {
+			Flags: complete.Flags{
+				"-env": complete.PredictSet("prod", "staging"),
+			},
+		},
+		"status": {},
+	},
+}
+
+func TestCompleter_Complete(t *testing.T) {
+	t.Parallel()
+	c := New(testCommand)
+
+	tests := []struct {
+		line      string
+		pos       int // -1 indicates len(line)
+		want      []string
+		wantStart int
+	}{
+		{line: "", pos: -1, want: []string{"deploy", "status"}, wantStart: 0},
+		{line: "de", pos: -1, want: []string{"deploy"}, wantStart: 0},
+		{line: "deploy -env ", pos: -1, want: []string{"prod", "staging"}, wantStart: 12},
+		{line: "deploy -env p", pos: -1, want: []string{"prod"}, wantStart: 12},
+		{line: "deploy -env=st", pos: -1, want: []string{"staging"}, wantStart: 12},
+		{line: "st deploy", pos: 2, want: []string{"status"}, wantStart: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if tt.pos == -1 {
+				tt.pos = len(tt.line)
+			}
+			got, start := c.Complete(tt.line, tt.pos)
+			sort.Strings(got)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantStart, start)
+		})
+	}
+}
+
+func TestCompleter_Do(t *testing.T) {
+	t.Parallel()
+	c := New(testCommand)
+
+	newLine, length := c.Do([]rune("deploy -env pr"), 14)
+	assert.Equal(t, [][]rune{[]rune("od")}, newLine)
+	assert.Equal(t, 2, length)
+}
+
+func TestCompleter_WordCompleter(t *testing.T) {
+	t.Parallel()
+	c := New(testCommand)
+
+	head, completions, tail := c.WordCompleter("deploy -env pr -x", 14)
+	assert.Equal(t, "deploy -env ", head)
+	assert.Equal(t, []string{"prod"}, completions)
+	assert.Equal(t, " -x", tail)
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package repl
+
+import "errors"
+
+func makeRaw(fd uintptr) (restore func() error, err error) {
+	return nil, errors.New("raw terminal mode is not supported")
+}
@@ -0,0 +1,122 @@
+package complete
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// trace records how a completion was computed: the parsed arguments, the
+// sub commands that were walked, the predictors that were chosen, with
+// their durations, and the final matches.
+// When the COMP_TRACE environment variable is set, the trace of every
+// completion is appended to the file it names, as a JSON object in a line.
+// A nil trace records nothing.
+type trace struct {
+	Time  time.Time `json:"time"`
+	Line  string    `json:"line"`
+	Point int       `json:"point"`
+	Args  traceArgs `json:"args"`
+	// Path are the sub commands that were walked.
+	Path        []string          `json:"path"`
+	Predictions []tracePrediction `json:"predictions"`
+	Options     int               `json:"options"`
+	Matches     []string          `json:"matches"`
+	Duration    string            `json:"duration"`
+	// Error is the error of a completion that failed.
+	Error string `json:"error,omitempty"`
+}
+
+type traceArgs struct {
+	All           []string `json:"all"`
+	Completed     []string `json:"completed"`
+	Last          string   `json:"last"`
+	LastCompleted string   `json:"last_completed"`
+}
+
+// tracePrediction is a call of a predictor.
+type tracePrediction struct {
+	// Predictor names the predictor, such as "flag -o" or "args".
+	Predictor string `json:"predictor"`
+	// Reason tells why the predictor was chosen.
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+	Count    int    `json:"count"`
+}
+
+// newTrace returns a trace for a completion if the trace environment
+// variable is set, otherwise nil.
+func newTrace() *trace {
+	if os.Getenv(envTrace) == "" {
+		return nil
+	}
+	return &trace{Time: time.Now()}
+}
+
+func (t *trace) args(line string, point int, a Args) {
+	if t == nil {
+		return
+	}
+	t.Line, t.Point = line, point
+	t.Args = traceArgs{All: a.All, Completed: a.Completed, Last: a.Last, LastCompleted: a.LastCompleted}
+}
+
+// sub records that the completion walked into a sub command.
+func (t *trace) sub(name string) {
+	if t == nil {
+		return
+	}
+	t.Path = append(t.Path, name)
+}
+
+// predict calls the predictor, and records its duration and the number of
+// options that it returned.
+func (t *trace) predict(name, reason string, p Predictor, a Args) []string {
+	if t == nil {
+		return p.Predict(a)
+	}
+	start := time.Now()
+	options := p.Predict(a)
+	t.Predictions = append(t.Predictions, tracePrediction{
+		Predictor: name,
+		Reason:    reason,
+		Duration:  time.Since(start).String(),
+		Count:     len(options),
+	})
+	return options
+}
+
+func (t *trace) result(options, matches []string) {
+	if t == nil {
+		return
+	}
+	t.Options, t.Matches = len(options), matches
+	t.Duration = time.Since(t.Time).String()
+}
+
+// fail records that the completion of the line failed with err.
+func (t *trace) fail(line string, point int, err error) {
+	if t == nil {
+		return
+	}
+	t.Line, t.Point = line, point
+	t.Error = err.Error()
+	t.Duration = time.Since(t.Time).String()
+}
+
+// write appends the trace to the trace file, failures are logged to l.
+func (t *trace) write(l Logger) {
+	if t == nil {
+		return
+	}
+	name := os.Getenv(envTrace)
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		l.Logf(LevelError, "Failed opening trace file %s: %v", name, err)
+		return
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(t); err != nil {
+		l.Logf(LevelError, "Failed writing trace: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package complete
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProblemKind is the kind of a problem in a command tree.
+type ProblemKind int
+
+const (
+	// DuplicateFlag is a flag that is both in the Flags and in the
+	// GlobalFlags of a command, or of one of its parents. Only one of its
+	// predictors is used.
+	DuplicateFlag ProblemKind = iota + 1
+	// SubCommandWithDash is a sub command whose name starts with a dash, it
+	// is completed only when the user types a flag.
+	SubCommandWithDash
+	// FlagWithoutDash is a flag whose name does not start with a dash, it is
+	// completed as if it was a sub command or an argument.
+	FlagWithoutDash
+	// EmptyPredictSet is a PredictSet without options, which never completes
+	// anything. PredictNothing should be used instead.
+	EmptyPredictSet
+	// ArgsWithSub is a command that has both arguments and sub commands,
+	// the argument predictions are mixed with the sub command names.
+	ArgsWithSub
+	// MissingFlag is a flag that the program defines, but the command tree
+	// does not complete.
+	MissingFlag
+	// StaleFlag is a flag that the command tree completes, but the program
+	// does not define.
+	StaleFlag
+	// FlagPredictorMismatch is a boolean flag that has a predictor, which
+	// completes a value that the program does not expect, or a flag that
+	// expects a value and has no predictor.
+	FlagPredictorMismatch
+	// UnknownCommand is a sub command that the program defines flags for,
+	// but is not in the command tree.
+	UnknownCommand
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case DuplicateFlag:
+		return "duplicate flag"
+	case SubCommandWithDash:
+		return "sub command with dash"
+	case FlagWithoutDash:
+		return "flag without dash"
+	case EmptyPredictSet:
+		return "empty predict set"
+	case ArgsWithSub:
+		return "args with sub commands"
+	case MissingFlag:
+		return "missing flag"
+	case StaleFlag:
+		return "stale flag"
+	case FlagPredictorMismatch:
+		return "flag predictor mismatch"
+	case UnknownCommand:
+		return "unknown command"
+	default:
+		return "unknown"
+	}
+}
+
+// Problem is a problem in a command tree.
+type Problem struct {
+	Kind ProblemKind
+	// Path are the names of the sub commands from the validated command to
+	// the command that has the problem.
+	Path []string
+	// Flag is the flag that has the problem, if it is of a flag.
+	Flag string
+	// Message describes the problem.
+	Message string
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	if len(p.Path) > 0 {
+		b.WriteString(strings.Join(p.Path, " ") + ": ")
+	}
+	if p.Flag != "" {
+		b.WriteString("flag " + p.Flag + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// Validate returns the problems in the command tree, in a stable order.
+// It can be used in unit tests, or when the program starts, to find
+// mistakes in the tree that would otherwise be found by users.
+func (c *Command) Validate() []Problem {
+	var v validator
+	v.command(c, nil, nil)
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(kind ProblemKind, path []string, flag, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Kind:    kind,
+		Path:    append([]string(nil), path...),
+		Flag:    flag,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// command validates a command in path, that inherits the global flags of
+// its parents in globals.
+func (v *validator) command(c *Command, path []string, globals map[string][]string) {
+	for _, name := range sortedKeys(c.GlobalFlags) {
+		v.flag(path, name, c.GlobalFlags[name])
+		if parent, ok := globals[name]; ok {
+			v.add(DuplicateFlag, path, name, "global flag is also a global flag of %s", commandName(parent))
+		}
+	}
+	for _, name := range sortedKeys(c.Flags) {
+		v.flag(path, name, c.Flags[name])
+		if _, ok := c.GlobalFlags[name]; ok {
+			v.add(DuplicateFlag, path, name, "flag is also a global flag")
+		} else if parent, ok := globals[name]; ok {
+			v.add(DuplicateFlag, path, name, "flag is also a global flag of %s", commandName(parent))
+		}
+	}
+	if c.Args != nil && len(c.Sub) > 0 {
+		v.add(ArgsWithSub, path, "", "command has both args and sub commands")
+	}
+	if isEmptySet(c.Args) {
+		v.add(EmptyPredictSet, path, "", "args predict an empty set, use PredictNothing")
+	}
+
+	// The global flags of the command are inherited by its sub commands.
+	inherited := make(map[string][]string, len(globals)+len(c.GlobalFlags))
+	for name, p := range globals {
+		inherited[name] = p
+	}
+	for name := range c.GlobalFlags {
+		if _, ok := inherited[name]; !ok {
+			inherited[name] = path
+		}
+	}
+
+	subs := make([]string, 0, len(c.Sub))
+	for name := range c.Sub {
+		subs = append(subs, name)
+	}
+	sort.Strings(subs)
+	for _, name := range subs {
+		subPath := append(append([]string(nil), path...), name)
+		if strings.HasPrefix(name, "-") {
+			v.add(SubCommandWithDash, subPath, "", "sub command name starts with a dash")
+		}
+		sub := c.Sub[name]
+		v.command(&sub, subPath, inherited)
+	}
+}
+
+func (v *validator) flag(path []string, name string, p Predictor) {
+	if !strings.HasPrefix(name, "-") {
+		v.add(FlagWithoutDash, path, name, "flag name does not start with a dash")
+	}
+	if isEmptySet(p) {
+		v.add(EmptyPredictSet, path, name, "flag predicts an empty set, use PredictNothing")
+	}
+}
+
+func isEmptySet(p Predictor) bool {
+	set, ok := p.(predictSet)
+	return ok && len(set) == 0
+}
+
+// commandName returns the name of the command in path for messages.
+func commandName(path []string) string {
+	if len(path) == 0 {
+		return "the root command"
+	}
+	return strings.Join(path, " ")
+}
+
+func sortedKeys(f Flags) []string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
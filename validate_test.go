@@ -0,0 +1,62 @@
+package complete
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommand_Validate(t *testing.T) {
+	t.Parallel()
+
+	c := Command{
+		Sub: Commands{
+			"sub": {
+				Flags: Flags{
+					"-global": PredictAnything,
+					"-ok":     PredictSet("a"),
+				},
+				Sub: Commands{
+					"-dash": {},
+				},
+				Args: PredictAnything,
+			},
+		},
+		Flags: Flags{
+			"-both":  PredictAnything,
+			"nodash": PredictNothing,
+			"-empty": PredictSet(),
+		},
+		GlobalFlags: Flags{
+			"-both":   PredictAnything,
+			"-global": PredictAnything,
+		},
+	}
+
+	want := []string{
+		"flag -both: flag is also a global flag",
+		"flag -empty: flag predicts an empty set, use PredictNothing",
+		"flag nodash: flag name does not start with a dash",
+		"sub: flag -global: flag is also a global flag of the root command",
+		"sub: command has both args and sub commands",
+		"sub -dash: sub command name starts with a dash",
+	}
+	wantKinds := []ProblemKind{DuplicateFlag, EmptyPredictSet, FlagWithoutDash, DuplicateFlag, ArgsWithSub, SubCommandWithDash}
+
+	problems := c.Validate()
+	var got []string
+	var gotKinds []ProblemKind
+	for _, p := range problems {
+		got = append(got, p.String())
+		gotKinds = append(gotKinds, p.Kind)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems:\n%q\nwant:\n%q", got, want)
+	}
+	if !reflect.DeepEqual(gotKinds, wantKinds) {
+		t.Errorf("got kinds %v, want %v", gotKinds, wantKinds)
+	}
+
+	if problems := (&Command{Sub: Commands{"sub": {Flags: Flags{"-f": PredictNothing}}}}).Validate(); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}